@@ -0,0 +1,149 @@
+package field
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+)
+
+type testPanicStringer struct{}
+
+func (s testPanicStringer) String() string { panic("boom") }
+
+func testAppendFields() Fields {
+	return Fields{
+		Nil("nil"),
+		Bool("bool", true),
+		Int8("int8", -8),
+		Uint64("uint64", math.MaxUint64),
+		Uintptr("uintptr", 0xbeef),
+		Float32("float32", 1.25),
+		Float64("float64", math.Inf(-1)),
+		Complex64("complex64", complex(1, -2)),
+		Complex128("complex128", complex(0, math.NaN())),
+		String("string", "a\"<b>\n "),
+		Binary("binary", []byte("hello")),
+		Time("time", time.Date(2006, 1, 2, 15, 4, 5, 0, time.FixedZone("", 3600))),
+		Duration("duration", 1500*time.Millisecond),
+		Stringer("nilStringer", (*testStringer)(nil)),
+		Stringer("panicStringer", testPanicStringer{}),
+		Error("error", errors.New("boom")),
+		Error("jsonError", fmt.Errorf("wrap: %w", testJSONError{})),
+		JsonRawMessage("json", []byte(`{"a":1}`)),
+		Ints("ints", []int{1, 2}),
+		Object("object", String("b", "x"), String("a", "y"), String("b", "z")),
+		{Key: "custom", Content: funcContent{fn: func() {}}},
+		String("string", "repeated"),
+	}
+}
+
+type testJSONError struct{}
+
+func (e testJSONError) Error() string { return "json" }
+
+func (e testJSONError) MarshalJSON() ([]byte, error) { return []byte(`{"code":1}`), nil }
+
+func TestAppendJSON(t *testing.T) {
+	var fields = testAppendFields()
+	t.Run("fields", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := fields.EncodeJSON(&buf); err != nil {
+			t.Errorf("cant encode fields: %v", err)
+			return
+		}
+		if result := fields.AppendJSON([]byte("prefix")); string(result) != "prefix"+buf.String() {
+			t.Errorf("invalid append result: %s, expected: prefix%s", result, buf.String())
+			return
+		}
+	})
+	t.Run("largeFields", func(t *testing.T) {
+		var large = append(append(Fields{}, fields...), fields...)
+		var buf bytes.Buffer
+		if err := large.EncodeJSON(&buf); err != nil {
+			t.Errorf("cant encode fields: %v", err)
+			return
+		}
+		if result := large.AppendJSON(nil); string(result) != buf.String() {
+			t.Errorf("invalid append result: %s, expected: %s", result, buf.String())
+			return
+		}
+	})
+	for _, f := range fields {
+		t.Run(f.Key, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := f.EncodeJSON(&buf); err != nil {
+				t.Errorf("cant encode field: %v", err)
+				return
+			}
+			if result := f.AppendJSON(nil); string(result) != buf.String() {
+				t.Errorf("invalid append result: %s, expected: %s", result, buf.String())
+				return
+			}
+		})
+	}
+}
+
+func TestAppendDuration(t *testing.T) {
+	var durations = []time.Duration{
+		0, 1, -1, 999, time.Microsecond, 1100 * time.Nanosecond, 2200 * time.Microsecond,
+		time.Second, 1500 * time.Millisecond, time.Minute, time.Hour + time.Second,
+		-(3*time.Hour + 25*time.Minute + 45*time.Second + 500*time.Millisecond),
+		math.MaxInt64, math.MinInt64,
+	}
+	for _, d := range durations {
+		if result := appendDuration(nil, d); string(result) != d.String() {
+			t.Errorf("invalid duration: %s, expected: %s", result, d.String())
+			return
+		}
+	}
+}
+
+func TestAppendJSONAllocs(t *testing.T) {
+	var fields = Fields{
+		Nil("nil"),
+		Bool("bool", true),
+		Int("int", 12345),
+		Uint64("uint64", math.MaxUint64),
+		Uintptr("uintptr", 0xbeef),
+		Float64("float64", 1.5),
+		Complex128("complex128", complex(1, 2)),
+		String("string", "hello \"world\""),
+		Binary("binary", []byte("hello")),
+		Time("time", time.Unix(0, 0).UTC()),
+		Duration("duration", time.Second),
+		Error("error", errors.New("boom")),
+		Ints("ints", []int{1, 2}),
+		Object("object", String("a", "b")),
+	}
+	var buf = make([]byte, 0, 4096)
+	if allocs := testing.AllocsPerRun(100, func() { buf = fields.AppendJSON(buf[:0]) }); allocs != 0 {
+		t.Errorf("invalid allocs per run: %v", allocs)
+		return
+	}
+}
+
+func BenchmarkAppendJSON(b *testing.B) {
+	var fields = Fields{
+		String("msg", "request done"), String("method", "GET"), String("path", "/api/v1/users"),
+		Int("status", 200), Float64("latency", 1.5), Duration("elapsed", 1500*time.Millisecond),
+		Time("time", time.Unix(1700000000, 0).UTC()), Bool("cached", false),
+	}
+	b.Run("AppendJSON", func(b *testing.B) {
+		var buf []byte
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			buf = fields.AppendJSON(buf[:0])
+		}
+	})
+	b.Run("EncodeJSON", func(b *testing.B) {
+		var buf bytes.Buffer
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			buf.Reset()
+			_ = fields.EncodeJSON(&buf)
+		}
+	})
+}
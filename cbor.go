@@ -0,0 +1,593 @@
+package field
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+const (
+	cborMajorUint   = 0 << 5
+	cborMajorNegInt = 1 << 5
+	cborMajorBytes  = 2 << 5
+	cborMajorText   = 3 << 5
+	cborMajorArray  = 4 << 5
+	cborMajorMap    = 5 << 5
+	cborMajorTag    = 6 << 5
+	cborMajorSimple = 7 << 5
+
+	cborFalse     = cborMajorSimple | 20
+	cborTrue      = cborMajorSimple | 21
+	cborNull      = cborMajorSimple | 22
+	cborUndefined = cborMajorSimple | 23
+	cborFloat16   = cborMajorSimple | 25
+	cborFloat32   = cborMajorSimple | 26
+	cborFloat64   = cborMajorSimple | 27
+	cborBreak     = cborMajorSimple | 31
+
+	cborIndefinite = 31
+
+	cborTagTimeString = 0
+	cborTagTimeEpoch  = 1
+)
+
+type CBORTimeFormat uint8
+
+const (
+	// CBORTimeEpoch encodes time as tag 1 of integer or float seconds, times
+	// a float cant hold exactly fall back to tag 0 to keep their nanoseconds.
+	CBORTimeEpoch CBORTimeFormat = iota
+	// CBORTimeString encodes time as tag 0 of RFC 3339 text.
+	CBORTimeString
+)
+
+// CBOREncoder encodes Fields as a CBOR (RFC 8949) map with each Type mapped
+// to its native data item. Complex numbers become an array of their real and
+// imaginary parts, errors and stringers become text.
+type CBOREncoder struct {
+	Time CBORTimeFormat
+}
+
+func (f Fields) EncodeCBOR(buf Buffer) error { return CBOREncoder{}.Encode(buf, f) }
+
+func (f Fields) MarshalCBOR() (dst []byte, err error) {
+	var buf bytes.Buffer
+	err = f.EncodeCBOR(&buf)
+	return buf.Bytes(), err
+}
+
+func (e CBOREncoder) Encode(buf Buffer, fields Fields) error {
+	return cborWriter{buf: buf, config: e, budget: loadSizeLimits().MaxTotal}.writeFields(fields)
+}
+
+func (e CBOREncoder) EncodeContent(buf Buffer, content Content) error {
+	return cborWriter{buf: buf, config: e, budget: loadSizeLimits().MaxTotal}.writeContent(content)
+}
+
+type cborWriter struct {
+	buf    Buffer
+	config CBOREncoder
+	// budget is the count of bytes a map may take, zero if unlimited.
+	budget int
+}
+
+func (w cborWriter) writeHead(major byte, n uint64) error {
+	var head [9]byte
+	switch {
+	case n < 24:
+		return w.buf.WriteByte(major | byte(n))
+	case n <= math.MaxUint8:
+		head[0], head[1] = major|24, byte(n)
+		return errWithoutVal(w.buf.Write(head[:2]))
+	case n <= math.MaxUint16:
+		head[0] = major | 25
+		binary.BigEndian.PutUint16(head[1:], uint16(n))
+		return errWithoutVal(w.buf.Write(head[:3]))
+	case n <= math.MaxUint32:
+		head[0] = major | 26
+		binary.BigEndian.PutUint32(head[1:], uint32(n))
+		return errWithoutVal(w.buf.Write(head[:5]))
+	default:
+		head[0] = major | 27
+		binary.BigEndian.PutUint64(head[1:], n)
+		return errWithoutVal(w.buf.Write(head[:9]))
+	}
+}
+
+func (w cborWriter) writeInt(v int64) error {
+	if v < 0 {
+		return w.writeHead(cborMajorNegInt, uint64(^v))
+	}
+	return w.writeHead(cborMajorUint, uint64(v))
+}
+
+func (w cborWriter) writeText(s string) (err error) {
+	if err = w.writeHead(cborMajorText, uint64(len(s))); err != nil {
+		return err
+	}
+	return errWithoutVal(w.buf.WriteString(s))
+}
+
+func (w cborWriter) writeFloat32(v float32) error {
+	var head [5]byte
+	head[0] = cborFloat32
+	binary.BigEndian.PutUint32(head[1:], math.Float32bits(v))
+	return errWithoutVal(w.buf.Write(head[:]))
+}
+
+func (w cborWriter) writeFloat64(v float64) error {
+	var head [9]byte
+	head[0] = cborFloat64
+	binary.BigEndian.PutUint64(head[1:], math.Float64bits(v))
+	return errWithoutVal(w.buf.Write(head[:]))
+}
+
+func (w cborWriter) writeFields(fields Fields) (err error) {
+	var snap = fields.Snapshot()
+	if w.budget > 0 {
+		return w.writeFieldsLimited(snap)
+	}
+	if err = w.writeHead(cborMajorMap, uint64(len(snap))); err != nil {
+		return err
+	}
+	for i := 0; i < len(snap); i++ {
+		if err = w.writeText(snap[i].Key); err != nil {
+			return err
+		}
+		if err = w.writeContent(snap[i].Content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeFieldsLimited writes snap as a map of at most w.budget bytes, fields
+// over it are dropped for a "!TRUNCATED" count.
+func (w cborWriter) writeFieldsLimited(snap []Field) (err error) {
+	var head sliceBuffer
+	_ = cborWriter{buf: &head}.writeHead(cborMajorMap, uint64(len(snap)+1))
+	var body, count, limitErr = appendLimited(nil, snap, w.budget-len(head.data), entryEncoder{
+		appendField: func(dst []byte, f Field, budget int) ([]byte, error) {
+			var buf = sliceBuffer{data: dst}
+			var fw = cborWriter{buf: &buf, config: w.config}
+			if err := fw.writeText(f.Key); err != nil {
+				return buf.data, err
+			}
+			fw.budget = nestedBudget(budget, len(buf.data)-len(dst))
+			var err = fw.writeContent(f.Content)
+			return buf.data, err
+		},
+		appendMarker: func(dst []byte, dropped int) []byte {
+			var buf = sliceBuffer{data: dst}
+			var fw = cborWriter{buf: &buf}
+			_ = fw.writeText(truncatedKey)
+			_ = fw.writeInt(int64(dropped))
+			return buf.data
+		},
+	})
+	if limitErr != nil {
+		return limitErr
+	}
+	if err = w.writeHead(cborMajorMap, uint64(count)); err != nil {
+		return err
+	}
+	return errWithoutVal(w.buf.Write(body))
+}
+
+func (w cborWriter) writeTime(t time.Time) (err error) {
+	var epoch = float64(t.Unix()) + float64(t.Nanosecond())/1e9
+	if w.config.Time == CBORTimeString || (t.Nanosecond() != 0 && !cborEpochTime(epoch).Equal(t)) {
+		if err = w.writeHead(cborMajorTag, cborTagTimeString); err != nil {
+			return err
+		}
+		return w.writeText(t.Format(time.RFC3339Nano))
+	}
+	if err = w.writeHead(cborMajorTag, cborTagTimeEpoch); err != nil {
+		return err
+	}
+	if t.Nanosecond() == 0 {
+		return w.writeInt(t.Unix())
+	}
+	return w.writeFloat64(epoch)
+}
+
+func (w cborWriter) writeContent(content Content) (err error) {
+	switch v := content.(type) {
+	case nil, NilContent:
+		return w.buf.WriteByte(cborNull)
+	case BoolContent:
+		if v {
+			return w.buf.WriteByte(cborTrue)
+		}
+		return w.buf.WriteByte(cborFalse)
+	case ArrayContent:
+		if err = w.writeHead(cborMajorArray, uint64(len(v.arrayRaw))); err != nil {
+			return err
+		}
+		for i := 0; i < len(v.arrayRaw); i++ {
+			if err = w.writeContent(v.arrayRaw[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	case ObjectContent:
+		return w.writeFields(v.fields)
+	case BinaryContent:
+		if err = w.writeHead(cborMajorBytes, uint64(len(v.binaryRaw))); err != nil {
+			return err
+		}
+		return errWithoutVal(w.buf.Write(v.binaryRaw))
+	case StringContent:
+		return w.writeText(string(v))
+	case Float32Content:
+		return w.writeFloat32(float32(v))
+	case Float64Content:
+		return w.writeFloat64(float64(v))
+	case Complex64Content:
+		if err = w.writeHead(cborMajorArray, 2); err != nil {
+			return err
+		}
+		if err = w.writeFloat32(real(v)); err != nil {
+			return err
+		}
+		return w.writeFloat32(imag(v))
+	case Complex128Content:
+		if err = w.writeHead(cborMajorArray, 2); err != nil {
+			return err
+		}
+		if err = w.writeFloat64(real(v)); err != nil {
+			return err
+		}
+		return w.writeFloat64(imag(v))
+	case TimeContent:
+		return w.writeTime(time.Time(v))
+	case TimeFormatContent:
+		return w.writeTime(v.data)
+	case LazyContent:
+		return w.writeContent(v.Raw())
+	case StackContent:
+		return w.writeContent(v.content())
+	case CallerContent:
+		return w.writeContent(v.content())
+	case UintptrContent:
+		return w.writeHead(cborMajorUint, uint64(v))
+	case ErrorContent:
+		if v.data == nil {
+			return w.buf.WriteByte(cborNull)
+		}
+		if text, panicked := SafeError(v.data); panicked != nil {
+			return w.writeContent(panicked)
+		} else {
+			return w.writeText(text)
+		}
+	case StringerContent:
+		if v.data == nil {
+			return w.buf.WriteByte(cborNull)
+		}
+		if text, panicked := SafeString(v.data); panicked != nil {
+			return w.writeContent(panicked)
+		} else {
+			return w.writeText(text)
+		}
+	case JSONContent:
+		if decoded, decodeErr := defaultDecoderConfig.DecodeContent(v.jsonRaw); decodeErr == nil {
+			return w.writeContent(decoded)
+		}
+		return w.writeText(string(v.jsonRaw))
+	}
+	switch content.Type() {
+	case TypeString:
+		if s, ok := content.Data().(string); ok {
+			return w.writeText(s)
+		}
+	case TypeInt:
+		if i, ok := IntData(content); ok {
+			return w.writeInt(i)
+		}
+	case TypeUint:
+		if u, ok := UintData(content); ok {
+			return w.writeHead(cborMajorUint, u)
+		}
+	}
+	var jsonBuf bytes.Buffer
+	if err = content.EncodeJSON(&jsonBuf); err != nil {
+		return err
+	}
+	return w.writeText(jsonBuf.String())
+}
+
+// decoder
+
+var errCBORTruncated = errors.New("cant unmarshal cbor: unexpected end of data")
+
+func (f *Fields) UnmarshalCBOR(data []byte) error {
+	var fields, err = DecodeCBOR(data)
+	if err != nil {
+		return err
+	}
+	*f = fields
+	return nil
+}
+
+// DecodeCBOR decodes a CBOR map to Fields. Integers take the best fitting of
+// int64 and uint64 content, tag 0 and 1 are recovered as TimeContent.
+func DecodeCBOR(data []byte) (Fields, error) {
+	var r = cborReader{data: data}
+	var content, err = r.readContent(0)
+	if err != nil {
+		return nil, err
+	}
+	if r.pos != len(r.data) {
+		return nil, fmt.Errorf("cant unmarshal cbor: unexpected data after top-level value")
+	}
+	if object, ok := content.(ObjectContent); ok {
+		return object.fields, nil
+	}
+	return nil, fmt.Errorf("cant unmarshal cbor: not a map")
+}
+
+type cborReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *cborReader) readByte() (byte, error) {
+	if r.pos >= len(r.data) {
+		return 0, errCBORTruncated
+	}
+	r.pos++
+	return r.data[r.pos-1], nil
+}
+
+func (r *cborReader) readN(n uint64) ([]byte, error) {
+	if n > uint64(len(r.data)-r.pos) {
+		return nil, errCBORTruncated
+	}
+	r.pos += int(n)
+	return r.data[r.pos-int(n) : r.pos], nil
+}
+
+// readArg reads the argument following initial byte, indefinite is reported
+// for additional information 31.
+func (r *cborReader) readArg(initial byte) (arg uint64, indefinite bool, err error) {
+	var info = initial & 0x1f
+	var raw []byte
+	switch {
+	case info < 24:
+		return uint64(info), false, nil
+	case info == 24:
+		raw, err = r.readN(1)
+	case info == 25:
+		raw, err = r.readN(2)
+	case info == 26:
+		raw, err = r.readN(4)
+	case info == 27:
+		raw, err = r.readN(8)
+	case info == cborIndefinite:
+		return 0, true, nil
+	default:
+		return 0, false, fmt.Errorf("cant unmarshal cbor: invalid additional information %d", info)
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	for i := 0; i < len(raw); i++ {
+		arg = arg<<8 | uint64(raw[i])
+	}
+	return arg, false, nil
+}
+
+func (r *cborReader) readContent(depth int) (Content, error) {
+	if depth > maxReflectDepth {
+		return nil, fmt.Errorf("cant unmarshal cbor: exceeded max depth %d", maxReflectDepth)
+	}
+	var initial, err = r.readByte()
+	if err != nil {
+		return nil, err
+	}
+	var major = initial & 0xe0
+	if major == cborMajorSimple {
+		return r.readSimple(initial)
+	}
+	var arg, indefinite, argErr = r.readArg(initial)
+	if argErr != nil {
+		return nil, argErr
+	}
+	switch major {
+	case cborMajorUint:
+		if arg <= math.MaxInt64 {
+			return NewIntContent(int64(arg)), nil
+		}
+		return NewUintContent(arg), nil
+	case cborMajorNegInt:
+		if arg <= math.MaxInt64 {
+			return NewIntContent(^int64(arg)), nil
+		}
+		return Float64Content(-1 - float64(arg)), nil
+	case cborMajorBytes, cborMajorText:
+		var raw []byte
+		if raw, err = r.readString(major, arg, indefinite); err != nil {
+			return nil, err
+		}
+		if major == cborMajorBytes {
+			return BinaryContent{binaryRaw: raw}, nil
+		}
+		return StringContent(raw), nil
+	case cborMajorArray:
+		var list = make([]Content, 0, r.capHint(arg, indefinite))
+		for i := uint64(0); indefinite || i < arg; i++ {
+			if indefinite && r.atBreak() {
+				break
+			}
+			var elem Content
+			if elem, err = r.readContent(depth + 1); err != nil {
+				return nil, err
+			}
+			list = append(list, elem)
+		}
+		return ArrayContent{arrayRaw: list}, nil
+	case cborMajorMap:
+		var fields = make(Fields, 0, r.capHint(arg, indefinite))
+		for i := uint64(0); indefinite || i < arg; i++ {
+			if indefinite && r.atBreak() {
+				break
+			}
+			var key, val Content
+			if key, err = r.readContent(depth + 1); err != nil {
+				return nil, err
+			}
+			if val, err = r.readContent(depth + 1); err != nil {
+				return nil, err
+			}
+			fields = append(fields, Field{Key: contentKeyString(key), Content: val})
+		}
+		return ObjectContent{fields: fields}, nil
+	case cborMajorTag:
+		if indefinite {
+			return nil, fmt.Errorf("cant unmarshal cbor: invalid tag")
+		}
+		var inner Content
+		if inner, err = r.readContent(depth + 1); err != nil {
+			return nil, err
+		}
+		return cborTagged(arg, inner)
+	}
+	return nil, fmt.Errorf("cant unmarshal cbor: invalid major type %d", major>>5)
+}
+
+// capHint limits preallocation by the remaining data, as each item takes
+// at least one byte.
+func (r *cborReader) capHint(arg uint64, indefinite bool) int {
+	if remain := uint64(len(r.data) - r.pos); indefinite || arg > remain {
+		return int(remain)
+	}
+	return int(arg)
+}
+
+func (r *cborReader) atBreak() bool {
+	if r.pos < len(r.data) && r.data[r.pos] == cborBreak {
+		r.pos++
+		return true
+	}
+	return false
+}
+
+func (r *cborReader) readString(major byte, length uint64, indefinite bool) ([]byte, error) {
+	if !indefinite {
+		var raw, err = r.readN(length)
+		return append([]byte(nil), raw...), err
+	}
+	var result = make([]byte, 0)
+	for !r.atBreak() {
+		var initial, err = r.readByte()
+		if err != nil {
+			return nil, err
+		}
+		if initial&0xe0 != major || initial&0x1f == cborIndefinite {
+			return nil, fmt.Errorf("cant unmarshal cbor: invalid chunk of indefinite string")
+		}
+		var chunkLen uint64
+		if chunkLen, _, err = r.readArg(initial); err != nil {
+			return nil, err
+		}
+		var chunk []byte
+		if chunk, err = r.readN(chunkLen); err != nil {
+			return nil, err
+		}
+		result = append(result, chunk...)
+	}
+	return result, nil
+}
+
+func (r *cborReader) readSimple(initial byte) (Content, error) {
+	switch initial {
+	case cborFalse:
+		return BoolContent(false), nil
+	case cborTrue:
+		return BoolContent(true), nil
+	case cborNull, cborUndefined:
+		return NilContent{}, nil
+	case cborFloat16:
+		var raw, err = r.readN(2)
+		if err != nil {
+			return nil, err
+		}
+		return Float32Content(float16ToFloat32(binary.BigEndian.Uint16(raw))), nil
+	case cborFloat32:
+		var raw, err = r.readN(4)
+		if err != nil {
+			return nil, err
+		}
+		return Float32Content(math.Float32frombits(binary.BigEndian.Uint32(raw))), nil
+	case cborFloat64:
+		var raw, err = r.readN(8)
+		if err != nil {
+			return nil, err
+		}
+		return Float64Content(math.Float64frombits(binary.BigEndian.Uint64(raw))), nil
+	}
+	return nil, fmt.Errorf("cant unmarshal cbor: unsupported simple value 0x%02x", initial)
+}
+
+func float16ToFloat32(h uint16) float32 {
+	var sign = uint32(h&0x8000) << 16
+	var exp, frac = uint32(h>>10) & 0x1f, uint32(h & 0x3ff)
+	switch exp {
+	case 0:
+		var f = float32(frac) / (1 << 24)
+		if sign != 0 {
+			return -f
+		}
+		return f
+	case 0x1f:
+		return math.Float32frombits(sign | 0x7f800000 | frac<<13)
+	}
+	return math.Float32frombits(sign | (exp+112)<<23 | frac<<13)
+}
+
+func cborTagged(tag uint64, inner Content) (Content, error) {
+	switch tag {
+	case cborTagTimeString:
+		if s, ok := inner.(StringContent); ok {
+			var t, err = time.Parse(time.RFC3339Nano, string(s))
+			if err != nil {
+				return nil, fmt.Errorf("cant unmarshal cbor time: %w", err)
+			}
+			return TimeContent(t), nil
+		}
+	case cborTagTimeEpoch:
+		switch v := inner.(type) {
+		case IntContent[int64]:
+			return TimeContent(time.Unix(v.data, 0)), nil
+		case Float64Content:
+			return TimeContent(cborEpochTime(float64(v))), nil
+		case Float32Content:
+			return TimeContent(cborEpochTime(float64(v))), nil
+		}
+	}
+	return inner, nil
+}
+
+func cborEpochTime(epoch float64) time.Time {
+	var sec, frac = math.Modf(epoch)
+	return time.Unix(int64(sec), int64(math.Round(frac*1e9)))
+}
+
+// contentKeyString stringifies decoded map keys which are not strings.
+func contentKeyString(key Content) string {
+	switch v := key.(type) {
+	case StringContent:
+		return string(v)
+	case IntContent[int64]:
+		return strconv.FormatInt(v.data, 10)
+	case UintContent[uint64]:
+		return strconv.FormatUint(v.data, 10)
+	}
+	var jsonBuf bytes.Buffer
+	_ = key.EncodeJSON(&jsonBuf)
+	return jsonBuf.String()
+}
@@ -0,0 +1,161 @@
+package field
+
+import (
+	"bytes"
+	stdhex "encoding/hex"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCBOREncoder(t *testing.T) {
+	var tests = []struct {
+		name    string
+		content Content
+		expect  string
+	}{
+		{"nil", NilContent{}, "f6"},
+		{"bool", BoolContent(true), "f5"},
+		{"uint", NewUintContent[uint64](1000), "1903e8"},
+		{"int", NewIntContent[int64](-1000), "3903e7"},
+		{"int8", NewIntContent[int8](10), "0a"},
+		{"uint64", NewUintContent[uint64](math.MaxUint64), "1bffffffffffffffff"},
+		{"uintptr", UintptrContent(24), "1818"},
+		{"float32", Float32Content(1.5), "fa3fc00000"},
+		{"float64", Float64Content(1.1), "fb3ff199999999999a"},
+		{"complex64", Complex64Content(1 - 2i), "82fa3f800000fac0000000"},
+		{"string", StringContent("IETF"), "6449455446"},
+		{"binary", NewBinaryContent([]byte{1, 2, 3, 4}), "4401020304"},
+		{"time", TimeContent(time.Unix(1363896240, 0)), "c11a514b67b0"},
+		{"timeFrac", TimeContent(time.Unix(1363896240, 5e8)), "c1fb41d452d9ec200000"},
+		{"error", NewErrorContent(errors.New("boom")), "64626f6f6d"},
+		{"stringer", NewStringerContent(time.Second), "623173"},
+		{"json", NewJSONContent([]byte(`{"a":[1]}`)), "a161618101"},
+		{"array", ArrayContent{arrayRaw: []Content{NewIntContent(1), NewIntContent(2)}}, "820102"},
+		{"object", NewObjectContent(Bool("b", false), Nil("a")), "a26161f66162f4"},
+	}
+	for _, testItem := range tests {
+		t.Run(testItem.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := (CBOREncoder{}).EncodeContent(&buf, testItem.content); err != nil {
+				t.Error(err)
+				return
+			}
+			if result := stdhex.EncodeToString(buf.Bytes()); result != testItem.expect {
+				t.Errorf("invalid cbor result: %s, expected: %s", result, testItem.expect)
+			}
+		})
+	}
+	t.Run("timeNanos", func(t *testing.T) {
+		var timeVal = time.Date(2023, 5, 20, 23, 15, 16, 123456789, time.UTC)
+		var buf bytes.Buffer
+		if err := (CBOREncoder{}).EncodeContent(&buf, TimeContent(timeVal)); err != nil {
+			t.Error(err)
+			return
+		}
+		if buf.Bytes()[0] != 0xc0 {
+			t.Errorf("invalid cbor tag for nanoseconds: %x", buf.Bytes())
+			return
+		}
+		var data, _ = (Fields{Time("t", timeVal)}).MarshalCBOR()
+		var decoded, err = DecodeCBOR(data)
+		if err != nil || len(decoded) != 1 {
+			t.Errorf("cant decode time: %v", err)
+			return
+		}
+		if result, ok := decoded[0].Content.(TimeContent); !ok || !time.Time(result).Equal(timeVal) {
+			t.Errorf("invalid decoded time: %#v, expected: %v", decoded[0].Content, timeVal)
+			return
+		}
+	})
+	t.Run("timeString", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := (CBOREncoder{Time: CBORTimeString}).EncodeContent(&buf, TimeContent(time.Date(2013, 3, 21, 20, 4, 0, 0, time.UTC))); err != nil {
+			t.Error(err)
+			return
+		}
+		if result := stdhex.EncodeToString(buf.Bytes()); result != "c074323031332d30332d32315432303a30343a30305a" {
+			t.Errorf("invalid cbor result: %s", result)
+		}
+	})
+}
+
+func TestCBORDecode(t *testing.T) {
+	var timeVal = time.Date(2023, 5, 20, 23, 15, 16, 0, time.UTC)
+	var source = Fields{
+		Nil("null"),
+		Bool("bool", true),
+		Int64("int", math.MinInt64),
+		Uint64("uint", math.MaxUint64),
+		Float32("float32", 1.5),
+		Float64("float64", -2.25),
+		String("string", "hello"),
+		Binary("binary", []byte{0x12, 0x34}),
+		Time("time", timeVal),
+		Ints("ints", []int{1, -2}),
+		Object("object", String("b", "x")),
+	}
+	var data, err = source.MarshalCBOR()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	var fields Fields
+	if err = fields.UnmarshalCBOR(data); err != nil {
+		t.Error(err)
+		return
+	}
+	var expects = map[string]Content{
+		"null":    NilContent{},
+		"bool":    BoolContent(true),
+		"int":     NewIntContent[int64](math.MinInt64),
+		"uint":    NewUintContent[uint64](math.MaxUint64),
+		"float32": Float32Content(1.5),
+		"float64": Float64Content(-2.25),
+		"string":  StringContent("hello"),
+		"binary":  BinaryContent{binaryRaw: []byte{0x12, 0x34}},
+		"ints":    ArrayContent{arrayRaw: []Content{NewIntContent[int64](1), NewIntContent[int64](-2)}},
+		"object":  ObjectContent{fields: Fields{String("b", "x")}},
+	}
+	for _, field := range fields {
+		if field.Key == "time" {
+			if decoded, ok := field.Content.(TimeContent); !ok || !time.Time(decoded).Equal(timeVal) {
+				t.Errorf("invalid decoded time: %#v", field.Content)
+			}
+			continue
+		}
+		if !reflect.DeepEqual(field.Content, expects[field.Key]) {
+			t.Errorf("invalid decoded field %q: %#v", field.Key, field.Content)
+		}
+	}
+	if len(fields) != len(source) {
+		t.Errorf("invalid decoded fields: %v", fields)
+	}
+	t.Run("spec", func(t *testing.T) {
+		// indefinite map holding float16 and indefinite text, int keys and tag 0 time
+		var raw, _ = stdhex.DecodeString("bf6161f93c00616b7f626869ff01c074323031332d30332d32315432303a30343a30305aff")
+		var fields, err = DecodeCBOR(raw)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		var expected = Fields{
+			{Key: "a", Content: Float32Content(1)},
+			{Key: "k", Content: StringContent("hi")},
+			{Key: "1", Content: TimeContent(time.Date(2013, 3, 21, 20, 4, 0, 0, time.UTC))},
+		}
+		if !reflect.DeepEqual(fields, expected) {
+			t.Errorf("invalid decoded fields: %#v", fields)
+		}
+	})
+	t.Run("invalid", func(t *testing.T) {
+		for _, input := range []string{"", "a1", "a16161", "81f6", "5b7fffffffffffffff", "a0a0"} {
+			var raw, _ = stdhex.DecodeString(input)
+			if _, err := DecodeCBOR(raw); err == nil {
+				t.Errorf("expect error for %s", input)
+			}
+		}
+	})
+}
@@ -0,0 +1,199 @@
+package field
+
+import "os"
+
+type ColorMode uint8
+
+const (
+	// ColorAuto colours output only if the buffer reports itself a terminal.
+	ColorAuto ColorMode = iota
+	ColorNever
+	ColorAlways
+)
+
+const (
+	ansiReset   = "\x1b[0m"
+	ansiFaint   = "\x1b[2m"
+	ansiRed     = "\x1b[31m"
+	ansiYellow  = "\x1b[33m"
+	ansiBlue    = "\x1b[34m"
+	ansiMagenta = "\x1b[35m"
+	ansiCyan    = "\x1b[36m"
+)
+
+// ConsoleEncoder renders fields for humans like `user=alice latency=12ms
+// err="boom"`, values are coloured by their Type.
+type ConsoleEncoder struct {
+	Color ColorMode
+}
+
+// EncodeConsole encodes fields with the default ConsoleEncoder.
+func (f Fields) EncodeConsole(buf Buffer) error { return ConsoleEncoder{}.Encode(buf, f) }
+
+func (e ConsoleEncoder) Encode(buf Buffer, fields Fields) error {
+	var color = e.Color == ColorAlways || (e.Color == ColorAuto && isTerminal(buf))
+	return consoleWriter{buf: buf, color: color, budget: loadSizeLimits().MaxTotal}.writeFields(fields)
+}
+
+// isTerminal reports whether buf is a terminal, buffers wrapping one can tell
+// by implementing `IsTerminal() bool`.
+func isTerminal(buf Buffer) bool {
+	switch v := buf.(type) {
+	case interface{ IsTerminal() bool }:
+		return v.IsTerminal()
+	case interface{ Stat() (os.FileInfo, error) }:
+		var info, err = v.Stat()
+		return err == nil && info.Mode()&os.ModeCharDevice != 0
+	}
+	return false
+}
+
+func consoleColor(typ Type) string {
+	switch typ &^ TypeArray {
+	case TypeError:
+		return ansiRed
+	case TypeInt, TypeUint, TypeUintptr, TypeFloat, TypeComplex:
+		return ansiCyan
+	case TypeBool:
+		return ansiYellow
+	case TypeTime:
+		return ansiMagenta
+	case TypeBinary, TypeJSON:
+		return ansiBlue
+	case TypeNull:
+		return ansiFaint
+	}
+	return ""
+}
+
+type consoleWriter struct {
+	buf   Buffer
+	color bool
+	// budget is the count of bytes fields may take, zero if unlimited.
+	budget int
+}
+
+func (w consoleWriter) writeFields(fields Fields) (err error) {
+	var snap = fields.Snapshot()
+	if w.budget > 0 {
+		var data []byte
+		var enc = entryEncoder{sep: " ", appendField: w.appendField, appendMarker: w.appendMarker}
+		if data, _, err = appendLimited(nil, snap, w.budget, enc); err != nil {
+			return err
+		}
+		return errWithoutVal(w.buf.Write(data))
+	}
+	for i := 0; i < len(snap); i++ {
+		if i > 0 {
+			if err = w.buf.WriteByte(' '); err != nil {
+				return err
+			}
+		}
+		if err = w.writeKey(snap[i].Key); err != nil {
+			return err
+		}
+		if err = w.writeContent(snap[i].Content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w consoleWriter) appendField(dst []byte, f Field, budget int) ([]byte, error) {
+	var buf = sliceBuffer{data: dst}
+	var fw = consoleWriter{buf: &buf, color: w.color}
+	if err := fw.writeKey(f.Key); err != nil {
+		return buf.data, err
+	}
+	fw.budget = nestedBudget(budget, len(buf.data)-len(dst))
+	var err = fw.writeContent(f.Content)
+	return buf.data, err
+}
+
+func (w consoleWriter) appendMarker(dst []byte, dropped int) []byte {
+	var buf = sliceBuffer{data: dst}
+	var fw = consoleWriter{buf: &buf, color: w.color}
+	_ = fw.writeKey(truncatedKey)
+	_ = fw.writeContent(Int("", dropped).Content)
+	return buf.data
+}
+
+func (w consoleWriter) writeKey(key string) (err error) {
+	if err = w.writeColored(ansiFaint, func() error { return writeLogfmtKey(w.buf, key) }); err != nil {
+		return err
+	}
+	return w.buf.WriteByte('=')
+}
+
+func (w consoleWriter) writeColored(color string, write func() error) (err error) {
+	if !w.color || color == "" {
+		return write()
+	}
+	if _, err = w.buf.WriteString(color); err != nil {
+		return err
+	}
+	if err = write(); err != nil {
+		return err
+	}
+	_, err = w.buf.WriteString(ansiReset)
+	return err
+}
+
+func (w consoleWriter) writeContent(content Content) error {
+	if lazy, ok := content.(LazyContent); ok {
+		content = lazy.Raw()
+	}
+	if content == nil {
+		return w.writeColored(ansiFaint, func() error { return NilContent{}.EncodeLogfmt(w.buf) })
+	}
+	var typ = content.Type()
+	switch {
+	case typ&TypeArray != 0:
+		if array, ok := content.(ArrayContent); ok {
+			return w.writeArray(array.arrayRaw)
+		}
+	case typ == TypeObject:
+		if object, ok := content.(ObjectContent); ok {
+			return w.writeObject(object.fields)
+		}
+	case typ == TypeError:
+		if err, ok := content.Data().(error); ok && err != nil {
+			var text, panicked = SafeError(err)
+			if panicked != nil {
+				return w.writeContent(panicked)
+			}
+			return w.writeColored(ansiRed, func() error {
+				return errWithoutVal(w.buf.Write(appendString(nil, text, false)))
+			})
+		}
+	}
+	return w.writeColored(consoleColor(typ), func() error { return encodeLogfmtContent(w.buf, content) })
+}
+
+func (w consoleWriter) writeArray(list []Content) (err error) {
+	if err = w.buf.WriteByte('['); err != nil {
+		return err
+	}
+	for i := 0; i < len(list); i++ {
+		if i > 0 {
+			if _, err = w.buf.WriteString(", "); err != nil {
+				return err
+			}
+		}
+		if err = w.writeContent(list[i]); err != nil {
+			return err
+		}
+	}
+	return w.buf.WriteByte(']')
+}
+
+func (w consoleWriter) writeObject(fields Fields) (err error) {
+	if err = w.buf.WriteByte('{'); err != nil {
+		return err
+	}
+	w.budget = nestedBudget(w.budget, len("{}"))
+	if err = w.writeFields(fields); err != nil {
+		return err
+	}
+	return w.buf.WriteByte('}')
+}
@@ -0,0 +1,79 @@
+package field
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+type testTerminalBuffer struct{ bytes.Buffer }
+
+func (b *testTerminalBuffer) IsTerminal() bool { return true }
+
+func TestConsoleEncoder(t *testing.T) {
+	var list = Fields{
+		String("user", "alice"),
+		Duration("latency", 12*time.Millisecond),
+		Error("err", errors.New("boom")),
+		Ints("ids", []int{1, 2}),
+		Object("req", String("path", "/a b"), Bool("tls", false)),
+		Nil("none"),
+	}
+	t.Run("plain", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := list.EncodeConsole(&buf); err != nil {
+			t.Error(fmt.Errorf("cant encode console: %w", err))
+			return
+		}
+		var expected = `err="boom" ids=[1, 2] latency=12ms none=null req={path="/a b" tls=false} user=alice`
+		if result := buf.String(); result != expected {
+			t.Errorf("invalid console result: `%s`, expected: `%s`", result, expected)
+			return
+		}
+	})
+	t.Run("lazy", func(t *testing.T) {
+		var lazyList = make(Fields, len(list))
+		for i := 0; i < len(list); i++ {
+			var content = list[i].Content
+			lazyList[i] = Lazy(list[i].Key, func() Content { return content })
+		}
+		var buf, lazyBuf bytes.Buffer
+		if err := list.EncodeConsole(&buf); err != nil {
+			t.Error(fmt.Errorf("cant encode console: %w", err))
+			return
+		}
+		if err := lazyList.EncodeConsole(&lazyBuf); err != nil {
+			t.Error(fmt.Errorf("cant encode console: %w", err))
+			return
+		}
+		if result, expected := lazyBuf.String(), buf.String(); result != expected {
+			t.Errorf("invalid console result: `%s`, expected: `%s`", result, expected)
+			return
+		}
+	})
+	t.Run("terminal", func(t *testing.T) {
+		var buf testTerminalBuffer
+		if err := (Fields{Error("err", errors.New("boom")), Ints("ids", []int{1})}).EncodeConsole(&buf); err != nil {
+			t.Error(fmt.Errorf("cant encode console: %w", err))
+			return
+		}
+		var expected = "\x1b[2merr\x1b[0m=\x1b[31m\"boom\"\x1b[0m \x1b[2mids\x1b[0m=[\x1b[36m1\x1b[0m]"
+		if result := buf.String(); result != expected {
+			t.Errorf("invalid console result: %q, expected: %q", result, expected)
+			return
+		}
+	})
+	t.Run("never", func(t *testing.T) {
+		var buf testTerminalBuffer
+		if err := (ConsoleEncoder{Color: ColorNever}).Encode(&buf, Fields{Int("n", 1)}); err != nil {
+			t.Error(fmt.Errorf("cant encode console: %w", err))
+			return
+		}
+		if result := buf.String(); result != "n=1" {
+			t.Errorf("invalid console result: %q", result)
+			return
+		}
+	})
+}
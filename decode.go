@@ -0,0 +1,206 @@
+package field
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type NumberMode uint8
+
+const (
+	// NumberBestFit decodes integers to int64, or uint64 when exceeding it,
+	// and all others to float64.
+	NumberBestFit NumberMode = iota
+	// NumberFloat decodes every number to float64, like encoding/json does.
+	NumberFloat
+	// NumberRaw keeps number literals untouched as JSONContent.
+	NumberRaw
+)
+
+// DecoderConfig controls how aggressive types are recovered from JSON, which
+// by itself only distinguishes null, bool, number, string, array and object.
+type DecoderConfig struct {
+	Number NumberMode
+	// Binary decodes strings of "data:;base64," prefix back to BinaryContent.
+	Binary bool
+	// Time decodes strings in RFC 3339 format to TimeContent.
+	Time bool
+}
+
+var defaultDecoderConfig = DecoderConfig{}
+
+var errNotObject = errors.New("cant unmarshal fields: not an object")
+
+// DecodeJSON decodes a JSON object to Fields, keeping order of its keys.
+func (c DecoderConfig) DecodeJSON(data []byte) (Fields, error) {
+	var decoder = c.newDecoder(data)
+	var token, err = decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	if token != json.Delim('{') {
+		return nil, errNotObject
+	}
+	var fields Fields
+	if fields, err = c.decodeObject(decoder, 0); err != nil {
+		return nil, err
+	}
+	return fields, c.checkEOF(decoder)
+}
+
+// DecodeContent decodes any JSON value to Content.
+func (c DecoderConfig) DecodeContent(data []byte) (Content, error) {
+	var decoder = c.newDecoder(data)
+	var content, err = c.decodeValue(decoder, 0)
+	if err != nil {
+		return nil, err
+	}
+	return content, c.checkEOF(decoder)
+}
+
+func (c DecoderConfig) newDecoder(data []byte) *json.Decoder {
+	var decoder = json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder
+}
+
+func (c DecoderConfig) checkEOF(decoder *json.Decoder) error {
+	if _, err := decoder.Token(); err != io.EOF {
+		return fmt.Errorf("cant unmarshal fields: unexpected data after top-level value")
+	}
+	return nil
+}
+
+// decodeValue decodes the next value, depth is the count of containers it
+// is nested in, which is bounded by maxReflectDepth like the CBOR and
+// MessagePack readers.
+func (c DecoderConfig) decodeValue(decoder *json.Decoder, depth int) (Content, error) {
+	if depth > maxReflectDepth {
+		return nil, fmt.Errorf("cant unmarshal fields: exceeded max depth %d", maxReflectDepth)
+	}
+	var token, err = decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch v := token.(type) {
+	case nil:
+		return NilContent{}, nil
+	case bool:
+		return BoolContent(v), nil
+	case json.Number:
+		return c.decodeNumber(v)
+	case string:
+		return c.decodeString(v), nil
+	case json.Delim:
+		switch v {
+		case '{':
+			var fields Fields
+			if fields, err = c.decodeObject(decoder, depth); err != nil {
+				return nil, err
+			}
+			return ObjectContent{fields: fields}, nil
+		case '[':
+			var list = make([]Content, 0)
+			for decoder.More() {
+				var elem Content
+				if elem, err = c.decodeValue(decoder, depth+1); err != nil {
+					return nil, err
+				}
+				list = append(list, elem)
+			}
+			if _, err = decoder.Token(); err != nil {
+				return nil, err
+			}
+			return ArrayContent{arrayRaw: list}, nil
+		}
+	}
+	return nil, fmt.Errorf("cant unmarshal fields: unexpected token %v", token)
+}
+
+// decodeObject decodes members of an object whose opening delim is consumed.
+func (c DecoderConfig) decodeObject(decoder *json.Decoder, depth int) (fields Fields, err error) {
+	fields = make(Fields, 0)
+	for decoder.More() {
+		var token json.Token
+		if token, err = decoder.Token(); err != nil {
+			return nil, err
+		}
+		var field = Field{Key: token.(string)}
+		if field.Content, err = c.decodeValue(decoder, depth+1); err != nil {
+			return nil, err
+		}
+		fields = append(fields, field)
+	}
+	if _, err = decoder.Token(); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+func (c DecoderConfig) decodeNumber(num json.Number) (Content, error) {
+	switch c.Number {
+	case NumberRaw:
+		return JSONContent{jsonRaw: json.RawMessage(num)}, nil
+	case NumberBestFit:
+		if !strings.ContainsAny(string(num), ".eE") {
+			if i, err := strconv.ParseInt(string(num), 10, 64); err == nil {
+				return NewIntContent(i), nil
+			}
+			if u, err := strconv.ParseUint(string(num), 10, 64); err == nil {
+				return NewUintContent(u), nil
+			}
+		}
+	}
+	var f, err = strconv.ParseFloat(string(num), 64)
+	if err != nil {
+		return nil, fmt.Errorf("cant unmarshal number: %w", err)
+	}
+	return Float64Content(f), nil
+}
+
+const binaryDataURIPrefix = "data:;base64,"
+
+func (c DecoderConfig) decodeString(s string) Content {
+	if c.Binary && strings.HasPrefix(s, binaryDataURIPrefix) {
+		var encoded = strings.TrimRight(s[len(binaryDataURIPrefix):], "=")
+		if data, err := base64.RawStdEncoding.DecodeString(encoded); err == nil {
+			return BinaryContent{binaryRaw: data}
+		}
+	}
+	if c.Time && len(s) >= len("2006-01-02T15:04:05Z") && s[4] == '-' {
+		if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+			return TimeContent(t)
+		}
+	}
+	return StringContent(s)
+}
+
+func (f *Fields) UnmarshalJSON(data []byte) (err error) {
+	var fields Fields
+	if fields, err = defaultDecoderConfig.DecodeJSON(data); err != nil {
+		return err
+	}
+	*f = fields
+	return nil
+}
+
+// UnmarshalJSON is the reverse of Field.MarshalJSON, which expects an object
+// holding exactly one member.
+func (f *Field) UnmarshalJSON(data []byte) (err error) {
+	var fields Fields
+	if fields, err = defaultDecoderConfig.DecodeJSON(data); err != nil {
+		return err
+	}
+	if len(fields) != 1 {
+		return fmt.Errorf("cant unmarshal field: expect 1 member, got %d", len(fields))
+	}
+	*f = fields[0]
+	return nil
+}
@@ -0,0 +1,141 @@
+package field
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDecodeJSON(t *testing.T) {
+	var timeVal = time.Date(2023, 5, 20, 23, 15, 16, 999111000, time.UTC)
+	var source = Fields{
+		Nil("null"),
+		Bool("bool", true),
+		Int64("int", math.MinInt64),
+		Uint64("uint", math.MaxUint64),
+		Float64("float", 1.5),
+		String("string", "hello"),
+		Binary("binary", []byte{0x12, 0x34}),
+		String("time", timeVal.Format(time.RFC3339Nano)),
+		Ints("ints", []int{1, 2}),
+		Object("object", String("b", "x"), Bool("a", false)),
+	}
+	var data, err = source.MarshalJSON()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	t.Run("unmarshal", func(t *testing.T) {
+		var fields Fields
+		if err = json.Unmarshal(data, &fields); err != nil {
+			t.Error(err)
+			return
+		}
+		var expects = map[string]Content{
+			"null":   NilContent{},
+			"bool":   BoolContent(true),
+			"int":    NewIntContent[int64](math.MinInt64),
+			"uint":   NewUintContent[uint64](math.MaxUint64),
+			"float":  Float64Content(1.5),
+			"string": StringContent("hello"),
+			"binary": StringContent("data:;base64,EjQ"),
+			"time":   StringContent(timeVal.Format(time.RFC3339Nano)),
+			"ints":   ArrayContent{arrayRaw: []Content{NewIntContent[int64](1), NewIntContent[int64](2)}},
+			"object": ObjectContent{fields: Fields{Bool("a", false), String("b", "x")}},
+		}
+		if len(fields) != len(expects) {
+			t.Errorf("invalid decoded fields: %v", fields)
+			return
+		}
+		for _, field := range fields {
+			if !reflect.DeepEqual(field.Content, expects[field.Key]) {
+				t.Errorf("invalid decoded field %q: %#v", field.Key, field.Content)
+			}
+		}
+	})
+	t.Run("recovery", func(t *testing.T) {
+		var fields, err = DecoderConfig{Binary: true, Time: true, Number: NumberFloat}.DecodeJSON(data)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		var expects = map[string]Content{
+			"int":    Float64Content(math.MinInt64),
+			"binary": BinaryContent{binaryRaw: []byte{0x12, 0x34}},
+			"time":   TimeContent(timeVal),
+		}
+		for key, expect := range expects {
+			if field, _ := fields.Get(key); !reflect.DeepEqual(field.Content, expect) {
+				t.Errorf("invalid decoded field %q: %#v", key, field.Content)
+			}
+		}
+	})
+	t.Run("numberRaw", func(t *testing.T) {
+		var content, err = DecoderConfig{Number: NumberRaw}.DecodeContent([]byte(`[1e400]`))
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if !reflect.DeepEqual(content, ArrayContent{arrayRaw: []Content{JSONContent{jsonRaw: json.RawMessage("1e400")}}}) {
+			t.Errorf("invalid decoded content: %#v", content)
+			return
+		}
+	})
+	t.Run("roundTrip", func(t *testing.T) {
+		var fields Fields
+		if err = fields.UnmarshalJSON(data); err != nil {
+			t.Error(err)
+			return
+		}
+		var result, _ = fields.MarshalJSON()
+		if string(result) != string(data) {
+			t.Errorf("invalid round trip result: `%s`, expected: `%s`", result, data)
+			return
+		}
+	})
+	t.Run("field", func(t *testing.T) {
+		var field Field
+		if err = field.UnmarshalJSON([]byte(`{"k":` + strconv.Quote("v") + `}`)); err != nil {
+			t.Error(err)
+			return
+		}
+		if field.Key != "k" || field.Content != StringContent("v") {
+			t.Errorf("invalid decoded field: %#v", field)
+			return
+		}
+		if err = field.UnmarshalJSON([]byte(`{"a":1,"b":2}`)); err == nil {
+			t.Errorf("expect error for multiple members")
+			return
+		}
+	})
+	t.Run("invalid", func(t *testing.T) {
+		for _, input := range []string{`[]`, `{"a":}`, `{"a":1} {}`, `{"a":1`} {
+			var fields Fields
+			if err := fields.UnmarshalJSON([]byte(input)); err == nil {
+				t.Errorf("expect error for %s, got %v", input, fields)
+			}
+		}
+	})
+	t.Run("depth", func(t *testing.T) {
+		var nested = func(n int) []byte {
+			return []byte(`{"a":` + strings.Repeat(`[`, n) + strings.Repeat(`]`, n) + `}`)
+		}
+		var fields Fields
+		if err := fields.UnmarshalJSON(nested(maxReflectDepth)); err != nil {
+			t.Errorf("cant unmarshal nested arrays: %v", err)
+			return
+		}
+		if err := fields.UnmarshalJSON(nested(100000)); err == nil || !strings.Contains(err.Error(), "exceeded max depth") {
+			t.Errorf("expect max depth error, got %v", err)
+			return
+		}
+		if _, err := (DecoderConfig{}).DecodeContent([]byte(strings.Repeat(`{"a":`, 100) + `1` + strings.Repeat(`}`, 100))); err == nil {
+			t.Errorf("expect max depth error for nested objects")
+			return
+		}
+	})
+}
@@ -0,0 +1,36 @@
+package field
+
+import "errors"
+
+var ErrDuplicateKey = errors.New("duplicate field key")
+
+// DuplicateKeys decides which content a key repeated in Fields resolves to.
+type DuplicateKeys uint8
+
+const (
+	// DuplicateFirstWins keeps the first occurrence, like Fields.Unique.
+	DuplicateFirstWins DuplicateKeys = iota
+	// DuplicateLastWins keeps the last occurrence, so later layers override.
+	DuplicateLastWins
+	// DuplicateMerge collects every occurrence into an array in order.
+	DuplicateMerge
+	// DuplicateError fails with ErrDuplicateKey.
+	DuplicateError
+)
+
+// Unique resolves repeated keys of fields by d and sorts them by key.
+func (d DuplicateKeys) Unique(fields Fields) ([]Field, error) {
+	return EncoderConfig{Duplicates: d}.Unique(fields)
+}
+
+// EncodeJSON works like Fields.EncodeJSON with repeated keys resolved by d,
+// nested objects included.
+func (d DuplicateKeys) EncodeJSON(buf Buffer, fields Fields) error {
+	return EncoderConfig{Duplicates: d}.EncodeJSON(buf, fields)
+}
+
+// Export works like Fields.Export with repeated keys resolved by d, nested
+// objects included.
+func (d DuplicateKeys) Export(fields Fields) (map[string]any, error) {
+	return EncoderConfig{Duplicates: d}.Export(fields)
+}
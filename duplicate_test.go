@@ -0,0 +1,65 @@
+package field
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestDuplicateKeys(t *testing.T) {
+	var fields = Fields{
+		String("user", "anon"),
+		Int("n", 1),
+		String("user", "alice"),
+		Object("obj", Int("a", 1), Int("a", 2)),
+	}
+	var tests = []struct {
+		name     string
+		strategy DuplicateKeys
+		expect   string
+	}{
+		{"FirstWins", DuplicateFirstWins, `{"n":1,"obj":{"a":1},"user":"anon"}`},
+		{"LastWins", DuplicateLastWins, `{"n":1,"obj":{"a":2},"user":"alice"}`},
+		{"Merge", DuplicateMerge, `{"n":1,"obj":{"a":[1,2]},"user":["anon","alice"]}`},
+	}
+	for _, testItem := range tests {
+		t.Run(testItem.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := testItem.strategy.EncodeJSON(&buf, fields); err != nil {
+				t.Errorf("cant encode fields: %v", err)
+				return
+			}
+			if buf.String() != testItem.expect {
+				t.Errorf("invalid json: %s, expected: %s", buf.String(), testItem.expect)
+				return
+			}
+		})
+	}
+	t.Run("Error", func(t *testing.T) {
+		if _, err := DuplicateError.Unique(fields); !errors.Is(err, ErrDuplicateKey) {
+			t.Errorf("invalid error: %v", err)
+			return
+		}
+		if err := DuplicateError.EncodeJSON(&bytes.Buffer{}, Fields{Object("obj", Int("a", 1), Int("a", 2))}); !errors.Is(err, ErrDuplicateKey) {
+			t.Errorf("invalid nested error: %v", err)
+			return
+		}
+		if _, err := DuplicateError.Unique(Fields{Int("a", 1), Int("b", 2)}); err != nil {
+			t.Errorf("unexpected error: %v", err)
+			return
+		}
+	})
+	t.Run("Export", func(t *testing.T) {
+		var result, err = DuplicateLastWins.Export(fields)
+		if err != nil {
+			t.Errorf("cant export fields: %v", err)
+			return
+		}
+		var expected = map[string]any{"n": 1, "obj": map[string]any{"a": 2}, "user": "alice"}
+		if !reflect.DeepEqual(result, expected) {
+			t.Errorf("invalid export: %v, expected: %v", result, expected)
+			return
+		}
+	})
+}
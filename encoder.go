@@ -0,0 +1,400 @@
+package field
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math"
+	"math/cmplx"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// KeyOrder decides the order fields are encoded in.
+type KeyOrder uint8
+
+const (
+	// KeySorted sorts fields by key, like Fields.Unique.
+	KeySorted KeyOrder = iota
+	// KeyInsertion keeps fields in the order they were added, a resolved
+	// repeated key takes the place of its first occurrence.
+	KeyInsertion
+)
+
+type BinaryFormat uint8
+
+const (
+	// BinaryDataURI writes binaries like "data:;base64,AQI".
+	BinaryDataURI BinaryFormat = iota
+	// BinaryBase64 writes binaries in padded standard base64.
+	BinaryBase64
+	// BinaryHex writes binaries in lower case hex.
+	BinaryHex
+)
+
+type UintptrFormat uint8
+
+const (
+	// UintptrHex writes uintptr as bare 0x prefixed hex padded to its width.
+	UintptrHex UintptrFormat = iota
+	// UintptrNumber writes uintptr as a decimal number.
+	UintptrNumber
+	// UintptrString writes uintptr as a quoted 0x prefixed hex.
+	UintptrString
+)
+
+type DurationFormat uint8
+
+const (
+	// DurationString writes durations like "1.5s".
+	DurationString DurationFormat = iota
+	// DurationNanos writes durations as integer nanoseconds.
+	DurationNanos
+	// DurationMillis writes durations as integer milliseconds.
+	DurationMillis
+	// DurationSeconds writes durations as float seconds.
+	DurationSeconds
+)
+
+type NonFiniteFormat uint8
+
+const (
+	// NonFiniteString writes NaN and infinities as "NaN", "+Inf" and "-Inf",
+	// complex values keep their string form.
+	NonFiniteString NonFiniteFormat = iota
+	// NonFiniteNull writes floats and complex values which are not finite
+	// as null.
+	NonFiniteNull
+	// NonFiniteError fails with ErrNonFinite.
+	NonFiniteError
+)
+
+var ErrNonFinite = errors.New("non finite float")
+
+// EncoderConfig controls how Fields are deduplicated, ordered and formatted
+// on encoding, the zero value behaves like Fields.EncodeJSON.
+type EncoderConfig struct {
+	Duplicates DuplicateKeys
+	Order      KeyOrder
+	// EscapeHTML escapes <, > and & in strings like encoding/json does.
+	EscapeHTML bool
+	// Time picks text in TimeLayout or a number since epoch for times.
+	Time TimeFormat
+	// TimeLayout is passed to time.Time.Format, time.RFC3339 if empty.
+	TimeLayout string
+	// TimeUTC converts times to UTC before formatting.
+	TimeUTC bool
+	// FloatFormat is passed to strconv.FormatFloat, one of 'f', 'e', 'E',
+	// 'g' and 'G'; others, which dont make JSON numbers, fall back to 'f'.
+	FloatFormat byte
+	Binary      BinaryFormat
+	Uintptr     UintptrFormat
+	Duration    DurationFormat
+	// NonFinite decides how NaN and infinities are written, in floats and
+	// complex values alike.
+	NonFinite NonFiniteFormat
+	// Error picks the message or the structured form for errors.
+	Error ErrorFormat
+	// Limits bounds sizes of values, the ones set by SetSizeLimits apply if
+	// it is zero.
+	Limits SizeLimits
+}
+
+// Unique resolves repeated keys of fields and orders them by c.
+func (c EncoderConfig) Unique(fields Fields) ([]Field, error) {
+	if c.Duplicates == DuplicateFirstWins && c.Order == KeySorted {
+		return fields.Unique(), nil
+	}
+	var index map[string]int
+	if len(fields) > smallFieldsLen {
+		index = make(map[string]int, len(fields))
+	}
+	var merged map[int][]Content
+	var result = make([]Field, 0, len(fields))
+	for i := 0; i < len(fields); i++ {
+		var pos, exist = findField(result, index, fields[i].Key)
+		if !exist {
+			if index != nil {
+				index[fields[i].Key] = len(result)
+			}
+			result = append(result, fields[i])
+			continue
+		}
+		switch c.Duplicates {
+		case DuplicateFirstWins:
+		case DuplicateLastWins:
+			result[pos] = fields[i]
+		case DuplicateMerge:
+			if merged == nil {
+				merged = make(map[int][]Content)
+			}
+			if _, ok := merged[pos]; !ok {
+				merged[pos] = []Content{result[pos].Content}
+			}
+			merged[pos] = append(merged[pos], fields[i].Content)
+		case DuplicateError:
+			return nil, fmt.Errorf("%w: %q", ErrDuplicateKey, fields[i].Key)
+		default:
+			return nil, fmt.Errorf("unknown duplicate key strategy %d", c.Duplicates)
+		}
+	}
+	for pos, list := range merged {
+		result[pos].Content = ArrayContent{arrayRaw: list}
+	}
+	if c.Order == KeySorted {
+		sort.SliceStable(result, func(i, j int) bool {
+			return strings.Compare(result[i].Key, result[j].Key) < 0
+		})
+	}
+	return result, nil
+}
+
+// smallFieldsLen is the length up to which repeated keys are found by
+// scanning instead of an index map.
+const smallFieldsLen = 16
+
+func findField(fields []Field, index map[string]int, key string) (int, bool) {
+	if index != nil {
+		var pos, exist = index[key]
+		return pos, exist
+	}
+	for i := 0; i < len(fields); i++ {
+		if fields[i].Key == key {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// EncodeJSON works like Fields.EncodeJSON with fields resolved and
+// formatted by c, nested objects included.
+func (c EncoderConfig) EncodeJSON(buf Buffer, fields Fields) error {
+	var data, err = c.AppendJSON(nil, fields)
+	if err != nil {
+		return err
+	}
+	return errWithoutVal(buf.Write(data))
+}
+
+// AppendJSON appends fields encoded like EncodeJSON to dst, on error dst is
+// returned as it was passed.
+func (c EncoderConfig) AppendJSON(dst []byte, fields Fields) (_ []byte, err error) {
+	var snap []Field
+	if snap, err = c.Unique(fields); err != nil {
+		return dst, err
+	}
+	var limits = c.limits()
+	snap = limits.limitFields(redactFields(snap))
+	return appendFieldsLimited(dst, snap, limits.MaxTotal, c.appendField)
+}
+
+func (c EncoderConfig) appendField(dst []byte, f Field, budget int) ([]byte, error) {
+	var start = len(dst)
+	dst = appendString(dst, f.Key, c.EscapeHTML)
+	dst = append(dst, ':')
+	if budget > 0 {
+		// nested objects share the budget of the field
+		c.Limits = c.limits()
+		c.Limits.MaxTotal = nestedBudget(budget, len(dst)-start)
+	}
+	var err error
+	if dst, err = c.appendContent(dst, f.Content); err != nil {
+		return dst[:start], err
+	}
+	return dst, nil
+}
+
+func (c EncoderConfig) limits() SizeLimits {
+	if c.Limits == (SizeLimits{}) {
+		return loadSizeLimits()
+	}
+	return c.Limits
+}
+
+func (c EncoderConfig) appendContent(dst []byte, content Content) (_ []byte, err error) {
+	switch v := content.(type) {
+	case LazyContent:
+		return c.appendContent(dst, v.Raw())
+	case ObjectContent:
+		return c.AppendJSON(dst, v.fields)
+	case ArrayContent:
+		var start = len(dst)
+		dst = append(dst, '[')
+		for i := 0; i < len(v.arrayRaw); i++ {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			var elem = c
+			if c.Limits.MaxTotal > 0 {
+				// objects of the array share the budget of the field
+				elem.Limits.MaxTotal = nestedBudget(c.Limits.MaxTotal, len(dst)-start)
+			}
+			if dst, err = elem.appendContent(dst, v.arrayRaw[i]); err != nil {
+				return dst[:start], err
+			}
+		}
+		return append(dst, ']'), nil
+	case StringContent:
+		return appendString(dst, string(v), c.EscapeHTML), nil
+	case Float32Content:
+		return c.appendFloat(dst, float64(v), 32)
+	case Float64Content:
+		return c.appendFloat(dst, float64(v), 64)
+	case Complex64Content:
+		return c.appendComplex(dst, complex128(v), 32)
+	case Complex128Content:
+		return c.appendComplex(dst, complex128(v), 64)
+	case TimeContent:
+		return c.appendTime(dst, time.Time(v), c.Time, c.TimeLayout), nil
+	case TimeFormatContent:
+		return c.appendTime(dst, v.data, v.format, v.layout), nil
+	case BinaryContent:
+		return c.appendBinary(dst, v.binaryRaw), nil
+	case UintptrContent:
+		return c.appendUintptr(dst, v), nil
+	case ErrorContent:
+		if c.Error == ErrorStructured {
+			return c.appendContent(dst, NewErrorTreeContent(v.data))
+		}
+		return appendErrorJSON(dst, v.data, c.EscapeHTML)
+	case StringerContent:
+		switch data := v.data.(type) {
+		case nil:
+			return append(dst, "null"...), nil
+		case time.Duration:
+			return c.appendDuration(dst, data), nil
+		}
+		if text, panicked := SafeString(v.data); panicked != nil {
+			return c.appendContent(dst, panicked)
+		} else {
+			return appendString(dst, text, c.EscapeHTML), nil
+		}
+	case jsonAppender:
+		return v.AppendJSON(dst), nil
+	}
+	var buf = sliceBuffer{data: dst}
+	if err = content.EncodeJSON(&buf); err != nil {
+		return dst, err
+	}
+	return buf.data, nil
+}
+
+func (c EncoderConfig) floatFormat() byte {
+	switch c.FloatFormat {
+	case 'f', 'e', 'E', 'g', 'G':
+		return c.FloatFormat
+	}
+	return 'f'
+}
+
+func (c EncoderConfig) appendFloat(dst []byte, f float64, bitSize int) ([]byte, error) {
+	if !math.IsNaN(f) && !math.IsInf(f, 0) {
+		return strconv.AppendFloat(dst, f, c.floatFormat(), -1, bitSize), nil
+	}
+	switch c.NonFinite {
+	case NonFiniteNull:
+		return append(dst, "null"...), nil
+	case NonFiniteError:
+		return dst, fmt.Errorf("cant marshal float: %w: %v", ErrNonFinite, f)
+	}
+	return appendFloatJSON(dst, f, c.floatFormat(), bitSize), nil
+}
+
+func (c EncoderConfig) appendComplex(dst []byte, v complex128, bitSize int) ([]byte, error) {
+	if !cmplx.IsNaN(v) && !cmplx.IsInf(v) {
+		return appendComplexJSON(dst, v, c.floatFormat(), bitSize), nil
+	}
+	switch c.NonFinite {
+	case NonFiniteNull:
+		return append(dst, "null"...), nil
+	case NonFiniteError:
+		return dst, fmt.Errorf("cant marshal complex: %w: %v", ErrNonFinite, v)
+	}
+	return appendComplexJSON(dst, v, c.floatFormat(), bitSize), nil
+}
+
+func (c EncoderConfig) appendTime(dst []byte, t time.Time, format TimeFormat, layout string) []byte {
+	if c.TimeUTC {
+		t = t.UTC()
+	}
+	return appendTimeJSON(dst, t, format, layout, c.EscapeHTML)
+}
+
+func (c EncoderConfig) appendBinary(dst []byte, data []byte) []byte {
+	var n int
+	switch c.Binary {
+	case BinaryBase64:
+		dst = append(dst, '"')
+		n = len(dst)
+		dst = append(dst, make([]byte, base64.StdEncoding.EncodedLen(len(data)))...)
+		base64.StdEncoding.Encode(dst[n:], data)
+	case BinaryHex:
+		dst = append(dst, '"')
+		for i := 0; i < len(data); i++ {
+			dst = append(dst, hex[data[i]>>4], hex[data[i]&0xF])
+		}
+	default:
+		return BinaryContent{binaryRaw: data}.AppendJSON(dst)
+	}
+	return append(dst, '"')
+}
+
+func (c EncoderConfig) appendUintptr(dst []byte, u UintptrContent) []byte {
+	switch c.Uintptr {
+	case UintptrNumber:
+		return strconv.AppendUint(dst, uint64(u), 10)
+	case UintptrString:
+		return append(u.AppendJSON(append(dst, '"')), '"')
+	}
+	return u.AppendJSON(dst)
+}
+
+func (c EncoderConfig) appendDuration(dst []byte, d time.Duration) []byte {
+	switch c.Duration {
+	case DurationNanos:
+		return strconv.AppendInt(dst, int64(d), 10)
+	case DurationMillis:
+		return strconv.AppendInt(dst, d.Milliseconds(), 10)
+	case DurationSeconds:
+		return strconv.AppendFloat(dst, d.Seconds(), c.floatFormat(), -1, 64)
+	}
+	dst = append(dst, '"')
+	return append(appendDuration(dst, d), '"')
+}
+
+// Export works like Fields.Export with fields resolved by c, nested objects
+// included.
+func (c EncoderConfig) Export(fields Fields) (_ map[string]any, err error) {
+	var snap []Field
+	if snap, err = c.Unique(fields); err != nil {
+		return nil, err
+	}
+	snap = redactFields(snap)
+	var m = make(map[string]any, len(snap))
+	for i := 0; i < len(snap); i++ {
+		if m[snap[i].Key], err = c.data(snap[i].Content); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+func (c EncoderConfig) data(content Content) (_ any, err error) {
+	switch v := content.(type) {
+	case LazyContent:
+		return c.data(v.Raw())
+	case ObjectContent:
+		return c.Export(v.fields)
+	case ArrayContent:
+		var list = make([]any, len(v.arrayRaw))
+		for i := 0; i < len(v.arrayRaw); i++ {
+			if list[i], err = c.data(v.arrayRaw[i]); err != nil {
+				return nil, err
+			}
+		}
+		return list, nil
+	}
+	return content.Data(), nil
+}
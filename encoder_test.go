@@ -0,0 +1,246 @@
+package field
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEncoderConfigOrder(t *testing.T) {
+	var fields = Fields{
+		String("msg", "hi"),
+		String("level", "info"),
+		String("user", "anon"),
+		Object("obj", Int("z", 1), Int("a", 2)),
+		String("user", "alice"),
+	}
+	var tests = []struct {
+		name   string
+		config EncoderConfig
+		expect string
+	}{
+		{"Sorted", EncoderConfig{}, `{"level":"info","msg":"hi","obj":{"a":2,"z":1},"user":"anon"}`},
+		{"Insertion", EncoderConfig{Order: KeyInsertion}, `{"msg":"hi","level":"info","user":"anon","obj":{"z":1,"a":2}}`},
+		{"InsertionLastWins", EncoderConfig{Order: KeyInsertion, Duplicates: DuplicateLastWins}, `{"msg":"hi","level":"info","user":"alice","obj":{"z":1,"a":2}}`},
+	}
+	for _, testItem := range tests {
+		t.Run(testItem.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := testItem.config.EncodeJSON(&buf, fields); err != nil {
+				t.Errorf("cant encode fields: %v", err)
+				return
+			}
+			if buf.String() != testItem.expect {
+				t.Errorf("invalid json: %s, expected: %s", buf.String(), testItem.expect)
+				return
+			}
+		})
+	}
+	t.Run("Large", func(t *testing.T) {
+		var large Fields
+		var keys []string
+		for i := 40; i > 0; i-- {
+			var key = fmt.Sprintf("k%d", i)
+			large = append(large, Int(key, i), Int(key, -i))
+			keys = append(keys, key)
+		}
+		var snap, err = EncoderConfig{Order: KeyInsertion}.Unique(large)
+		if err != nil || len(snap) != len(keys) {
+			t.Errorf("invalid unique fields: %v, %v", snap, err)
+			return
+		}
+		for i := 0; i < len(snap); i++ {
+			if snap[i].Key != keys[i] || strings.HasPrefix(fmt.Sprint(snap[i].Data()), "-") {
+				t.Errorf("invalid field at %d: %s=%v", i, snap[i].Key, snap[i].Data())
+				return
+			}
+		}
+	})
+	t.Run("Map", func(t *testing.T) {
+		var m = map[string]int{}
+		for i := 0; i < 20; i++ {
+			m[fmt.Sprintf("k%02d", i)] = i
+		}
+		var expected, _ = EncoderConfig{}.AppendJSON(nil, Fields{Map("m", m)})
+		for i := 0; i < 10; i++ {
+			if result, err := (EncoderConfig{Order: KeyInsertion}).AppendJSON(nil, Fields{Map("m", m)}); err != nil || string(result) != string(expected) {
+				t.Errorf("invalid map json: %s, expected: %s", result, expected)
+				return
+			}
+		}
+	})
+}
+
+func TestEncoderConfigFormat(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		var fields = testAppendFields()
+		var expected, _ = fields.MarshalJSON()
+		var buf bytes.Buffer
+		if err := (EncoderConfig{}).EncodeJSON(&buf, fields); err != nil {
+			t.Errorf("cant encode fields: %v", err)
+			return
+		}
+		if buf.String() != string(expected) {
+			t.Errorf("invalid json: %s, expected: %s", buf.String(), expected)
+			return
+		}
+	})
+	var fields = Fields{
+		String("html", "<a&b>"),
+		Float64("float", 1500),
+		Complex64("complex", complex(1, 2)),
+		Time("time", time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC)),
+		Binary("binary", []byte("hi")),
+		Uintptr("uintptr", 255),
+		Duration("duration", 1500*time.Millisecond),
+		Durations("durations", []time.Duration{time.Second}),
+	}
+	var tests = []struct {
+		name   string
+		config EncoderConfig
+		expect string
+	}{
+		{"browser", EncoderConfig{EscapeHTML: true, Binary: BinaryBase64, Uintptr: UintptrString},
+			`{"binary":"aGk=","complex":"(1+2i)","duration":"1.5s","durations":["1s"],"float":1500,` +
+				`"html":"\u003ca\u0026b\u003e","time":"2006-01-02T15:04:05Z","uintptr":"0x00000000000000ff"}`},
+		{"elasticsearch", EncoderConfig{TimeLayout: "2006-01-02 15:04:05.000", Duration: DurationMillis, Uintptr: UintptrNumber, Binary: BinaryHex},
+			`{"binary":"6869","complex":"(1+2i)","duration":1500,"durations":[1000],"float":1500,` +
+				`"html":"<a&b>","time":"2006-01-02 15:04:05.000","uintptr":255}`},
+		{"scientific", EncoderConfig{FloatFormat: 'e', Duration: DurationSeconds},
+			`{"binary":"data:;base64,aGk","complex":"(1e+00+2e+00i)","duration":1.5e+00,"durations":[1e+00],"float":1.5e+03,` +
+				`"html":"<a&b>","time":"2006-01-02T15:04:05Z","uintptr":0x00000000000000ff}`},
+	}
+	for _, testItem := range tests {
+		t.Run(testItem.name, func(t *testing.T) {
+			var result, err = testItem.config.AppendJSON(nil, fields)
+			if err != nil {
+				t.Errorf("cant encode fields: %v", err)
+				return
+			}
+			if string(result) != testItem.expect {
+				t.Errorf("invalid json: %s, expected: %s", result, testItem.expect)
+				return
+			}
+		})
+	}
+	t.Run("invalidFloatFormat", func(t *testing.T) {
+		var expected, _ = EncoderConfig{}.AppendJSON(nil, fields)
+		for _, format := range []byte{'b', 'x', 'X', 'q'} {
+			var result, _ = EncoderConfig{FloatFormat: format}.AppendJSON(nil, fields)
+			if string(result) != string(expected) {
+				t.Errorf("invalid json of format %q: %s, expected: %s", format, result, expected)
+				return
+			}
+		}
+	})
+}
+
+func TestEncoderConfigTime(t *testing.T) {
+	var timeA = time.Date(2006, 1, 2, 15, 4, 5, 123456789, time.FixedZone("", 3600))
+	var fields = Fields{
+		Time("time", timeA),
+		Times("times", []time.Time{timeA}),
+		TimeLayout("layout", timeA, time.Kitchen),
+	}
+	var tests = []struct {
+		name   string
+		config EncoderConfig
+		expect string
+	}{
+		{"default", EncoderConfig{}, `{"layout":"3:04PM","time":"2006-01-02T15:04:05+01:00","times":["2006-01-02T15:04:05+01:00"]}`},
+		{"nano", EncoderConfig{TimeLayout: time.RFC3339Nano},
+			`{"layout":"3:04PM","time":"2006-01-02T15:04:05.123456789+01:00","times":["2006-01-02T15:04:05.123456789+01:00"]}`},
+		{"utc", EncoderConfig{TimeLayout: time.RFC3339Nano, TimeUTC: true},
+			`{"layout":"2:04PM","time":"2006-01-02T14:04:05.123456789Z","times":["2006-01-02T14:04:05.123456789Z"]}`},
+		{"millis", EncoderConfig{Time: TimeUnixMillis}, `{"layout":"3:04PM","time":1136210645123,"times":[1136210645123]}`},
+	}
+	for _, testItem := range tests {
+		t.Run(testItem.name, func(t *testing.T) {
+			var result, err = testItem.config.AppendJSON(nil, fields)
+			if err != nil {
+				t.Errorf("cant encode fields: %v", err)
+				return
+			}
+			if string(result) != testItem.expect {
+				t.Errorf("invalid json: %s, expected: %s", result, testItem.expect)
+				return
+			}
+		})
+	}
+}
+
+func TestEncoderConfigNonFinite(t *testing.T) {
+	var fields = Fields{
+		Float64("nan", math.NaN()),
+		Float32("inf", float32(math.Inf(1))),
+		Float64s("floats", []float64{1, math.Inf(-1)}),
+		Complex128("complex", complex(1, math.Inf(1))),
+	}
+	t.Run("default", func(t *testing.T) {
+		var result, err = fields.MarshalJSON()
+		if err != nil || !json.Valid(result) {
+			t.Errorf("invalid json: %s, %v", result, err)
+			return
+		}
+		if expected := `{"complex":"(1+Infi)","floats":[1,"-Inf"],"inf":"+Inf","nan":"NaN"}`; string(result) != expected {
+			t.Errorf("invalid json: %s, expected: %s", result, expected)
+			return
+		}
+	})
+	t.Run("null", func(t *testing.T) {
+		var result, err = EncoderConfig{NonFinite: NonFiniteNull}.AppendJSON(nil, fields)
+		if expected := `{"complex":null,"floats":[1,null],"inf":null,"nan":null}`; err != nil || string(result) != expected {
+			t.Errorf("invalid json: %s, expected: %s, %v", result, expected, err)
+			return
+		}
+	})
+	t.Run("error", func(t *testing.T) {
+		var nested = Fields{
+			Int("a", 1),
+			Objects("n", []Fields{{Float64("x", 1)}, {Float64("x", math.NaN())}}),
+		}
+		var configs = []EncoderConfig{{NonFinite: NonFiniteError}, {NonFinite: NonFiniteError, Limits: SizeLimits{MaxTotal: 1024}}}
+		for _, config := range configs {
+			for _, f := range append(nested, fields...) {
+				if f.Key == "a" {
+					continue
+				}
+				var result, err = config.AppendJSON([]byte("prefix"), append(Fields{nested[0]}, f))
+				if !errors.Is(err, ErrNonFinite) {
+					t.Errorf("invalid error of %s: %v", f.Key, err)
+					return
+				}
+				if string(result) != "prefix" {
+					t.Errorf("invalid result of %s on error: %s", f.Key, result)
+					return
+				}
+			}
+		}
+		if _, err := (EncoderConfig{NonFinite: NonFiniteError}).AppendJSON(nil, Fields{Float64s("k", []float64{1})}); err != nil {
+			t.Errorf("unexpected error: %v", err)
+			return
+		}
+	})
+}
+
+func BenchmarkEncoderConfigOrder(b *testing.B) {
+	var fields = Fields{
+		String("msg", "hi"), String("level", "info"), Int("status", 200),
+		String("path", "/"), String("method", "GET"), Float64("latency", 1.5),
+	}
+	for _, config := range []EncoderConfig{{}, {Order: KeyInsertion}} {
+		b.Run(fmt.Sprintf("Order%d", config.Order), func(b *testing.B) {
+			var buf bytes.Buffer
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				buf.Reset()
+				_ = config.EncodeJSON(&buf, fields)
+			}
+		})
+	}
+}
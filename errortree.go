@@ -0,0 +1,99 @@
+package field
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// maxErrorDepth bounds how deep causes of an error are walked.
+const maxErrorDepth = 16
+
+// FieldsCarrier is implemented by errors carrying fields, which are picked
+// up into the structured form of the errors wrapping them.
+type FieldsCarrier interface {
+	Fields() Fields
+}
+
+type ErrorFormat uint8
+
+const (
+	// ErrorText writes errors as their message or by their MarshalJSON.
+	ErrorText ErrorFormat = iota
+	// ErrorStructured writes errors like ErrorTree does.
+	ErrorStructured
+)
+
+// NewErrorTreeContent converts err to an object like
+// {"msg":"...","type":"*os.PathError","fields":{...},"causes":[...]},
+// causes are walked through `Unwrap() error` and `Unwrap() []error`. Causes
+// deeper than maxErrorDepth or seen on the path already are not walked, the
+// object of their error is marked with "truncated":true instead.
+func NewErrorTreeContent(err error) Content {
+	if err == nil {
+		return NilContent{}
+	}
+	return errorTree(err, nil)
+}
+
+func ErrorTree(key string, err error) Field {
+	return Field{Key: key, Content: NewErrorTreeContent(err)}
+}
+
+func errorTree(err error, path []error) (content Content) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			content = panicContent(err, recovered)
+		}
+	}()
+	var fields = make(Fields, 0, 4)
+	if text, panicked := SafeError(err); panicked != nil {
+		fields = append(fields, Field{Key: "msg", Content: panicked})
+	} else {
+		fields = append(fields, String("msg", text))
+	}
+	fields = append(fields, String("type", fmt.Sprintf("%T", err)))
+	if carrier, ok := err.(FieldsCarrier); ok {
+		if attached := carrier.Fields(); len(attached) > 0 {
+			fields = append(fields, Object("fields", attached...))
+		}
+	}
+	var causes []error
+	switch v := err.(type) {
+	case interface{ Unwrap() error }:
+		if cause := v.Unwrap(); cause != nil {
+			causes = []error{cause}
+		}
+	case interface{ Unwrap() []error }:
+		for _, cause := range v.Unwrap() {
+			if cause != nil {
+				causes = append(causes, cause)
+			}
+		}
+	}
+	if len(causes) == 0 {
+		return ObjectContent{fields: fields}
+	}
+	if len(path) >= maxErrorDepth || errorInPath(err, path) {
+		return ObjectContent{fields: append(fields, Bool("truncated", true))}
+	}
+	path = append(path, err)
+	var list = make([]Content, len(causes))
+	for i := 0; i < len(causes); i++ {
+		list[i] = errorTree(causes[i], path[:len(path):len(path)])
+	}
+	return ObjectContent{fields: append(fields, Field{Key: "causes", Content: ArrayContent{arrayRaw: list}})}
+}
+
+// errorInPath reports whether err equals any error in path, errors of
+// incomparable types never do.
+func errorInPath(err error, path []error) bool {
+	if !reflect.TypeOf(err).Comparable() {
+		return false
+	}
+	for i := 0; i < len(path); i++ {
+		if reflect.TypeOf(path[i]) == reflect.TypeOf(err) && path[i] == err {
+			return true
+		}
+	}
+	return false
+}
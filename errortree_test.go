@@ -0,0 +1,86 @@
+package field
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+type testJoinError []error
+
+func (e testJoinError) Error() string { return "joined" }
+
+func (e testJoinError) Unwrap() []error { return e }
+
+type testCycleError struct{ cause error }
+
+func (e *testCycleError) Error() string { return "cycle" }
+
+func (e *testCycleError) Unwrap() error { return e.cause }
+
+type testFieldsError struct {
+	error
+	fields Fields
+}
+
+func (e testFieldsError) Unwrap() error { return e.error }
+
+func (e testFieldsError) Fields() Fields { return e.fields }
+
+func TestErrorTree(t *testing.T) {
+	var pathErr = &fs.PathError{Op: "open", Path: "/etc/app.yml", Err: syscall.ENOENT}
+	var cycleErr = &testCycleError{}
+	cycleErr.cause = cycleErr
+	var tests = []struct {
+		name   string
+		err    error
+		expect string
+	}{
+		{"nil", nil, `null`},
+		{"leaf", errors.New("boom"), `{"msg":"boom","type":"*errors.errorString"}`},
+		{"chain", fmt.Errorf("load: %w", pathErr),
+			`{"causes":[{"causes":[{"msg":"no such file or directory","type":"syscall.Errno"}],` +
+				`"msg":"open /etc/app.yml: no such file or directory","type":"*fs.PathError"}],` +
+				`"msg":"load: open /etc/app.yml: no such file or directory","type":"*fmt.wrapError"}`},
+		{"join", testJoinError{errors.New("a"), nil, errors.New("b")},
+			`{"causes":[{"msg":"a","type":"*errors.errorString"},{"msg":"b","type":"*errors.errorString"}],"msg":"joined","type":"field.testJoinError"}`},
+		{"fields", testFieldsError{error: errors.New("boom"), fields: Fields{String("user", "alice")}},
+			`{"causes":[{"msg":"boom","type":"*errors.errorString"}],"fields":{"user":"alice"},"msg":"boom","type":"field.testFieldsError"}`},
+		{"cycle", cycleErr,
+			`{"causes":[{"msg":"cycle","truncated":true,"type":"*field.testCycleError"}],"msg":"cycle","type":"*field.testCycleError"}`},
+		{"nilError", (*testPanicError)(nil), `{"msg":{"!PANIC":"` + testNilPanic + `","type":"*field.testPanicError"},"type":"*field.testPanicError"}`},
+	}
+	for _, testItem := range tests {
+		t.Run(testItem.name, func(t *testing.T) {
+			var result = NewErrorTreeContent(testItem.err).(jsonAppender).AppendJSON(nil)
+			if string(result) != testItem.expect {
+				t.Errorf("invalid error tree: %s, expected: %s", result, testItem.expect)
+				return
+			}
+		})
+	}
+	t.Run("depth", func(t *testing.T) {
+		var err = errors.New("root")
+		for i := 0; i < maxErrorDepth+4; i++ {
+			err = fmt.Errorf("wrap%d: %w", i, err)
+		}
+		var result = string(NewErrorTreeContent(err).(jsonAppender).AppendJSON(nil))
+		if strings.Count(result, `"causes"`) != maxErrorDepth || !strings.Contains(result, `"truncated":true`) || strings.Contains(result, `"msg":"root"`) {
+			t.Errorf("invalid error tree depth: %s", result)
+			return
+		}
+	})
+	t.Run("config", func(t *testing.T) {
+		var fields = Fields{Error("err", fmt.Errorf("load: %w", errors.New("boom"))), Errors("errs", []error{errors.New("a")})}
+		var result, err = EncoderConfig{Error: ErrorStructured}.AppendJSON(nil, fields)
+		var expected = `{"err":{"causes":[{"msg":"boom","type":"*errors.errorString"}],"msg":"load: boom","type":"*fmt.wrapError"},` +
+			`"errs":[{"msg":"a","type":"*errors.errorString"}]}`
+		if err != nil || string(result) != expected {
+			t.Errorf("invalid json: %s, expected: %s, %v", result, expected, err)
+			return
+		}
+	})
+}
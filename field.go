@@ -54,14 +54,22 @@ func (f Fields) Get(key string) (Field, bool) {
 
 func (f Fields) Export() map[string]any {
 	var m = make(map[string]any, len(f))
+	var policy = loadRedactionPolicy()
 	for i := 0; i < len(f); i++ {
-		m[f[i].Key] = f[i].Data()
+		m[f[i].Key] = policy.Redact(f[i]).Data()
 	}
 	return m
 }
 
 func (f Fields) EncodeJSON(buf Buffer) (err error) {
-	var snap = f.Unique()
+	var snap = f.Snapshot()
+	if maxTotal := loadSizeLimits().MaxTotal; maxTotal > 0 {
+		var data []byte
+		if data, err = appendFieldsLimited(nil, snap, maxTotal, jsonFieldAppender{strict: true}.appendField); err != nil {
+			return err
+		}
+		return errWithoutVal(buf.Write(data))
+	}
 	if err = buf.WriteByte('{'); err != nil {
 		return err
 	}
@@ -71,7 +79,7 @@ func (f Fields) EncodeJSON(buf Buffer) (err error) {
 				return err
 			}
 		}
-		if err = snap[i].EncodeJSON(buf); err != nil {
+		if err = snap[i].encodeJSON(buf); err != nil {
 			return err
 		}
 	}
@@ -81,6 +89,87 @@ func (f Fields) EncodeJSON(buf Buffer) (err error) {
 	return nil
 }
 
+// AppendJSON appends fields encoded like EncodeJSON to dst, it does not
+// allocate for fewer than smallFieldsLen fields of primitive contents
+// without size limits.
+func (f Fields) AppendJSON(dst []byte) []byte {
+	if limits := loadSizeLimits(); len(f) > smallFieldsLen || limits != (SizeLimits{}) {
+		return valWithoutErr(appendFieldsLimited(dst, f.Snapshot(), limits.MaxTotal, jsonFieldAppender{}.appendField))
+	}
+	// insertion sort of indexes on stack, skipping repeated keys so the
+	// first occurrence wins like Unique
+	var order [smallFieldsLen]int
+	var count = 0
+	for i := 0; i < len(f); i++ {
+		var pos = sort.Search(count, func(j int) bool { return f[order[j]].Key >= f[i].Key })
+		if pos < count && f[order[pos]].Key == f[i].Key {
+			continue
+		}
+		copy(order[pos+1:count+1], order[pos:count])
+		order[pos] = i
+		count++
+	}
+	var policy = loadRedactionPolicy()
+	dst = append(dst, '{')
+	for i := 0; i < count; i++ {
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+		dst = policy.Redact(f[order[i]]).appendJSON(dst)
+	}
+	return append(dst, '}')
+}
+
+// jsonFieldAppender appends fields of a snapshot for appendFieldsLimited,
+// like AppendJSON, or like EncodeJSON failing on errors of contents if strict.
+type jsonFieldAppender struct{ strict bool }
+
+func (a jsonFieldAppender) appendField(dst []byte, f Field, budget int) ([]byte, error) {
+	var start = len(dst)
+	dst = appendString(dst, f.Key, false)
+	dst = append(dst, ':')
+	return a.appendContent(dst, f.Content, nestedBudget(budget, len(dst)-start))
+}
+
+// appendFieldLimited appends f like appendField in at most maxTotal bytes,
+// or the "!TRUNCATED" count if it does not fit.
+func (a jsonFieldAppender) appendFieldLimited(dst []byte, f Field, maxTotal int) (_ []byte, err error) {
+	var enc = entryEncoder{sep: ",", appendField: a.appendField, appendMarker: appendJSONMarker}
+	dst, _, err = appendLimited(dst, []Field{f}, maxTotal, enc)
+	return dst, err
+}
+
+// appendContent appends content, nested objects share budget instead of
+// being limited to MaxTotal of their own.
+func (a jsonFieldAppender) appendContent(dst []byte, content Content, budget int) (_ []byte, err error) {
+	if budget > 0 {
+		switch v := content.(type) {
+		case LazyContent:
+			return a.appendContent(dst, v.Raw(), budget)
+		case ObjectContent:
+			return appendFieldsLimited(dst, v.fields.Snapshot(), budget, a.appendField)
+		case ArrayContent:
+			var start = len(dst)
+			dst = append(dst, '[')
+			for i := 0; i < len(v.arrayRaw); i++ {
+				if i > 0 {
+					dst = append(dst, ',')
+				}
+				if dst, err = a.appendContent(dst, v.arrayRaw[i], nestedBudget(budget, len(dst)-start)); err != nil {
+					return dst[:start], err
+				}
+			}
+			return append(dst, ']'), nil
+		}
+	}
+	if !a.strict {
+		return appendContentJSON(dst, content), nil
+	}
+	var buf = sliceBuffer{data: dst}
+	err = content.EncodeJSON(&buf)
+	return buf.data, err
+}
+
 func (f Fields) MarshalJSON() (dst []byte, err error) {
 	var buf bytes.Buffer
 	err = f.EncodeJSON(&buf)
@@ -104,6 +193,7 @@ const (
 	TypeError
 	TypeJSON
 	TypeAny
+	TypeObject
 	TypeArray = 0x80
 )
 
@@ -124,12 +214,45 @@ type Content interface {
 	EncodeJSON(buffer Buffer) error
 }
 
+// jsonAppender is implemented by contents which can append their JSON text
+// without a Buffer, builtin contents implement it.
+type jsonAppender interface {
+	AppendJSON(dst []byte) []byte
+}
+
+// appendContentJSON appends JSON text of content to dst. Contents without
+// AppendJSON are written through EncodeJSON, an error of which is appended
+// as a string instead.
+func appendContentJSON(dst []byte, content Content) []byte {
+	if appender, ok := content.(jsonAppender); ok {
+		return appender.AppendJSON(dst)
+	}
+	var buf = sliceBuffer{data: dst}
+	if err := content.EncodeJSON(&buf); err != nil {
+		return appendString(dst, err.Error(), false)
+	}
+	return buf.data
+}
+
 type Field struct {
 	Key string
 	Content
 }
 
-func (f Field) EncodeJSON(buffer Buffer) (err error) {
+// EncodeJSON writes field as `"key":value` with the redaction policy and the
+// size limits applied.
+func (f Field) EncodeJSON(buffer Buffer) error {
+	if maxTotal := loadSizeLimits().MaxTotal; maxTotal > 0 {
+		var data, err = jsonFieldAppender{strict: true}.appendFieldLimited(nil, f.snapshot(), maxTotal)
+		if err != nil {
+			return err
+		}
+		return errWithoutVal(buffer.Write(data))
+	}
+	return f.snapshot().encodeJSON(buffer)
+}
+
+func (f Field) encodeJSON(buffer Buffer) (err error) {
 	if err = appendJsonStringBuf(buffer, f.Key); err != nil {
 		return err
 	}
@@ -139,18 +262,28 @@ func (f Field) EncodeJSON(buffer Buffer) (err error) {
 	return f.Content.EncodeJSON(buffer)
 }
 
-func (f Field) MarshalJSON() (_ []byte, err error) {
-	var buf bytes.Buffer
-	if err = buf.WriteByte('{'); err != nil {
-		return nil, err
-	}
-	if err = f.EncodeJSON(&buf); err != nil {
-		return nil, err
+// AppendJSON appends field encoded like EncodeJSON to dst.
+func (f Field) AppendJSON(dst []byte) []byte {
+	if maxTotal := loadSizeLimits().MaxTotal; maxTotal > 0 {
+		return valWithoutErr(jsonFieldAppender{}.appendFieldLimited(dst, f.snapshot(), maxTotal))
 	}
-	if err = buf.WriteByte('}'); err != nil {
+	return f.snapshot().appendJSON(dst)
+}
+
+func (f Field) appendJSON(dst []byte) []byte {
+	dst = appendString(dst, f.Key, false)
+	dst = append(dst, ':')
+	return appendContentJSON(dst, f.Content)
+}
+
+// MarshalJSON encodes field as an object of its own, MaxTotal covers the
+// braces like for Fields.
+func (f Field) MarshalJSON() ([]byte, error) {
+	var data, err = Fields{f}.MarshalJSON()
+	if err != nil {
 		return nil, err
 	}
-	return buf.Bytes(), nil
+	return data, nil
 }
 
 // array wrapper
@@ -184,7 +317,30 @@ func (f ArrayContent) Data() any {
 
 func (f ArrayContent) Raw() []Content { return f.arrayRaw }
 
+// AppendJSON appends the array, objects in it share MaxTotal instead of
+// being limited to it each.
+func (f ArrayContent) AppendJSON(dst []byte) []byte {
+	if maxTotal := loadSizeLimits().MaxTotal; maxTotal > 0 {
+		return valWithoutErr(jsonFieldAppender{}.appendContent(dst, f, maxTotal))
+	}
+	dst = append(dst, '[')
+	for i := 0; i < len(f.arrayRaw); i++ {
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+		dst = appendContentJSON(dst, f.arrayRaw[i])
+	}
+	return append(dst, ']')
+}
+
 func (f ArrayContent) EncodeJSON(buffer Buffer) (err error) {
+	if maxTotal := loadSizeLimits().MaxTotal; maxTotal > 0 {
+		var data []byte
+		if data, err = (jsonFieldAppender{strict: true}).appendContent(nil, f, maxTotal); err != nil {
+			return err
+		}
+		return errWithoutVal(buffer.Write(data))
+	}
 	if err = buffer.WriteByte('['); err != nil {
 		return err
 	}
@@ -204,6 +360,30 @@ func (f ArrayContent) EncodeJSON(buffer Buffer) (err error) {
 	return nil
 }
 
+// object wrapper
+
+type ObjectContent struct{ fields Fields }
+
+func NewObjectContent(fields ...Field) Content { return ObjectContent{fields: fields} }
+
+func (f ObjectContent) Type() Type { return TypeObject }
+
+func (f ObjectContent) Data() any { return f.fields.Export() }
+
+func (f ObjectContent) Raw() Fields { return f.fields }
+
+func (f ObjectContent) AppendJSON(dst []byte) []byte { return f.fields.AppendJSON(dst) }
+
+func (f ObjectContent) EncodeJSON(buffer Buffer) error { return f.fields.EncodeJSON(buffer) }
+
+func Object(key string, fields ...Field) Field {
+	return Field{Key: key, Content: NewObjectContent(fields...)}
+}
+
+func Objects(key string, valArr []Fields) Field {
+	return Field{Key: key, Content: newArray(valArr, func(fields Fields) Content { return ObjectContent{fields: fields} })}
+}
+
 type NilContent struct{}
 
 func NewNilContent() Content { return NilContent{} }
@@ -212,6 +392,8 @@ func (n NilContent) Type() Type { return TypeNull }
 
 func (n NilContent) Data() any { return nil }
 
+func (n NilContent) AppendJSON(dst []byte) []byte { return append(dst, "null"...) }
+
 func (n NilContent) EncodeJSON(buffer Buffer) error {
 	return errWithoutVal(buffer.Write([]byte{'n', 'u', 'l', 'l'}))
 }
@@ -230,6 +412,8 @@ func (f JSONContent) Data() any { return f.jsonRaw }
 
 func (f JSONContent) Raw() json.RawMessage { return f.jsonRaw }
 
+func (f JSONContent) AppendJSON(dst []byte) []byte { return append(dst, f.jsonRaw...) }
+
 func (f JSONContent) EncodeJSON(buffer Buffer) (err error) {
 	return errWithoutVal(buffer.Write(f.jsonRaw))
 }
@@ -252,15 +436,20 @@ func (f BinaryContent) Raw() json.RawMessage { return f.binaryRaw }
 
 func (f BinaryContent) String() string { return base64.StdEncoding.EncodeToString(f.binaryRaw) }
 
+func (f BinaryContent) AppendJSON(dst []byte) []byte {
+	dst = append(dst, '"')
+	dst = append(dst, binaryDataURIPrefix...)
+	var n = len(dst)
+	dst = append(dst, make([]byte, base64.RawStdEncoding.EncodedLen(len(f.binaryRaw)))...)
+	base64.RawStdEncoding.Encode(dst[n:], f.binaryRaw)
+	return append(dst, '"')
+}
+
 func (f BinaryContent) EncodeJSON(buffer Buffer) (err error) {
-	if _, err = buffer.WriteString(`"data:;base64,`); err != nil {
-		return err
-	}
-	var encoder = base64.NewEncoder(base64.RawStdEncoding, buffer)
-	if _, err = encoder.Write(f.binaryRaw); err != nil {
+	if err = buffer.WriteByte('"'); err != nil {
 		return err
 	}
-	if err = encoder.Close(); err != nil {
+	if err = encodeBinaryDataURI(buffer, f.binaryRaw); err != nil {
 		return err
 	}
 	if err = buffer.WriteByte('"'); err != nil {
@@ -269,6 +458,17 @@ func (f BinaryContent) EncodeJSON(buffer Buffer) (err error) {
 	return nil
 }
 
+func encodeBinaryDataURI(buffer Buffer, data []byte) (err error) {
+	if _, err = buffer.WriteString(binaryDataURIPrefix); err != nil {
+		return err
+	}
+	var encoder = base64.NewEncoder(base64.RawStdEncoding, buffer)
+	if _, err = encoder.Write(data); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
 func Binary(key string, val []byte) Field { return Field{key, NewBinaryContent(val)} }
 
 func Binarys(key string, valArr [][]byte) Field {
@@ -287,6 +487,8 @@ func (f BoolContent) Data() any { return bool(f) }
 
 func (f BoolContent) Raw() bool { return bool(f) }
 
+func (f BoolContent) AppendJSON(dst []byte) []byte { return strconv.AppendBool(dst, bool(f)) }
+
 func (f BoolContent) EncodeJSON(buffer Buffer) error {
 	if f {
 		return errWithoutVal(buffer.Write([]byte{'t', 'r', 'u', 'e'}))
@@ -311,8 +513,12 @@ func (f Complex128Content) Data() any { return complex128(f) }
 
 func (f Complex128Content) Raw() complex128 { return complex128(f) }
 
+func (f Complex128Content) AppendJSON(dst []byte) []byte {
+	return appendComplexJSON(dst, complex128(f), 'f', 64)
+}
+
 func (f Complex128Content) EncodeJSON(buffer Buffer) (err error) {
-	return appendJsonStringBuf(buffer, strconv.FormatComplex(complex128(f), 'f', -1, 128))
+	return errWithoutVal(buffer.Write(f.AppendJSON(nil)))
 }
 
 func Complex128(key string, val complex128) Field {
@@ -335,8 +541,12 @@ func (f Complex64Content) Data() any { return complex64(f) }
 
 func (f Complex64Content) Raw() complex64 { return complex64(f) }
 
+func (f Complex64Content) AppendJSON(dst []byte) []byte {
+	return appendComplexJSON(dst, complex128(f), 'f', 32)
+}
+
 func (f Complex64Content) EncodeJSON(buffer Buffer) (err error) {
-	return appendJsonStringBuf(buffer, strconv.FormatComplex(complex128(f), 'f', -1, 64))
+	return errWithoutVal(buffer.Write(f.AppendJSON(nil)))
 }
 
 func Complex64(key string, val complex64) Field {
@@ -359,11 +569,16 @@ func (f ErrorContent) Data() any { return f.data }
 
 func (f ErrorContent) Raw() error { return f.data }
 
-func (f ErrorContent) EncodeJSON(buffer Buffer) error {
-	if f.data == nil {
-		return errWithoutVal(buffer.Write([]byte{'n', 'u', 'l', 'l'}))
+func (f ErrorContent) AppendJSON(dst []byte) []byte {
+	if result, err := appendErrorJSON(dst, f.data, false); err == nil {
+		return result
+	} else {
+		return appendString(dst, err.Error(), false)
 	}
-	if content, err := asJsonErrMarshaler(f.data).MarshalJSON(); err == nil {
+}
+
+func (f ErrorContent) EncodeJSON(buffer Buffer) error {
+	if content, err := appendErrorJSON(nil, f.data, false); err == nil {
 		return errWithoutVal(buffer.Write(content))
 	} else {
 		return err
@@ -386,8 +601,14 @@ func (f Float32Content) Data() any { return float32(f) }
 
 func (f Float32Content) Raw() float32 { return float32(f) }
 
+// AppendJSON writes NaN and infinities as strings "NaN", "+Inf" and "-Inf",
+// which JSON numbers cant hold.
+func (f Float32Content) AppendJSON(dst []byte) []byte {
+	return appendFloatJSON(dst, float64(f), 'f', 32)
+}
+
 func (f Float32Content) EncodeJSON(buffer Buffer) (err error) {
-	return errWithoutVal(buffer.WriteString(strconv.FormatFloat(float64(f), 'f', -1, 32)))
+	return errWithoutVal(buffer.Write(f.AppendJSON(nil)))
 }
 
 func Float32(key string, val float32) Field { return Field{Key: key, Content: NewFloat32Content(val)} }
@@ -406,8 +627,14 @@ func (f Float64Content) Data() any { return float64(f) }
 
 func (f Float64Content) Raw() float64 { return float64(f) }
 
+// AppendJSON writes NaN and infinities as strings "NaN", "+Inf" and "-Inf",
+// which JSON numbers cant hold.
+func (f Float64Content) AppendJSON(dst []byte) []byte {
+	return appendFloatJSON(dst, float64(f), 'f', 64)
+}
+
 func (f Float64Content) EncodeJSON(buffer Buffer) (err error) {
-	return errWithoutVal(buffer.WriteString(strconv.FormatFloat(float64(f), 'f', -1, 64)))
+	return errWithoutVal(buffer.Write(f.AppendJSON(nil)))
 }
 
 func Float64(key string, val float64) Field { return Field{Key: key, Content: NewFloat64Content(val)} }
@@ -430,6 +657,10 @@ func (f IntContent[T]) Data() any { return f.data }
 
 func (f IntContent[T]) Raw() T { return f.data }
 
+func (f IntContent[T]) AppendJSON(dst []byte) []byte {
+	return strconv.AppendInt(dst, int64(f.data), 10)
+}
+
 func (f IntContent[T]) EncodeJSON(buffer Buffer) (err error) {
 	return errWithoutVal(buffer.WriteString(strconv.FormatInt(int64(f.data), 10)))
 }
@@ -490,6 +721,10 @@ func (f UintContent[T]) Data() any { return f.data }
 
 func (f UintContent[T]) Raw() T { return f.data }
 
+func (f UintContent[T]) AppendJSON(dst []byte) []byte {
+	return strconv.AppendUint(dst, uint64(f.data), 10)
+}
+
 func (f UintContent[T]) EncodeJSON(buffer Buffer) (err error) {
 	return errWithoutVal(buffer.WriteString(strconv.FormatUint(uint64(f.data), 10)))
 }
@@ -534,6 +769,48 @@ func Uint64s(key string, nums []uint64) Field {
 	return Field{key, newArray(nums, NewUintContent[uint64])}
 }
 
+// IntData returns the value of contents holding a signed integer of any
+// width, like IntContent whose type parameter keeps a single type switch
+// case from matching it.
+func IntData(content Content) (int64, bool) {
+	if content == nil {
+		return 0, false
+	}
+	switch v := content.Data().(type) {
+	case int:
+		return int64(v), true
+	case int8:
+		return int64(v), true
+	case int16:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	}
+	return 0, false
+}
+
+// UintData is IntData for unsigned integers, like of UintContent.
+func UintData(content Content) (uint64, bool) {
+	if content == nil {
+		return 0, false
+	}
+	switch v := content.Data().(type) {
+	case uint:
+		return uint64(v), true
+	case uint8:
+		return uint64(v), true
+	case uint16:
+		return uint64(v), true
+	case uint32:
+		return uint64(v), true
+	case uint64:
+		return v, true
+	}
+	return 0, false
+}
+
 // data type: uintptr
 
 type UintptrContent uintptr
@@ -546,8 +823,19 @@ func (f UintptrContent) Data() any { return uintptr(f) }
 
 func (f UintptrContent) Raw() uintptr { return uintptr(f) }
 
+// AppendJSON writes f like `%#0*x` of fmt, zero padded to the width of uintptr.
+func (f UintptrContent) AppendJSON(dst []byte) []byte {
+	dst = append(dst, '0', 'x')
+	for shift := 8*unsafe.Sizeof(f) - 4; ; shift -= 4 {
+		dst = append(dst, hex[uintptr(f)>>shift&0xF])
+		if shift == 0 {
+			return dst
+		}
+	}
+}
+
 func (f UintptrContent) EncodeJSON(buffer Buffer) (err error) {
-	return errWithoutVal(fmt.Fprintf(buffer, "%#0*x", 2*unsafe.Sizeof(f), f.Data()))
+	return errWithoutVal(buffer.Write(f.AppendJSON(nil)))
 }
 
 func Uintptr(key string, val uintptr) Field {
@@ -570,6 +858,8 @@ func (f StringContent) Data() any { return string(f) }
 
 func (f StringContent) Raw() string { return string(f) }
 
+func (f StringContent) AppendJSON(dst []byte) []byte { return appendString(dst, string(f), false) }
+
 func (f StringContent) EncodeJSON(buffer Buffer) error {
 	return appendJsonStringBuf(buffer, string(f))
 }
@@ -606,11 +896,23 @@ func (f StringerContent) Data() any { return f.data }
 
 func (f StringerContent) Raw() fmt.Stringer { return f.data }
 
-func (f StringerContent) EncodeJSON(buffer Buffer) (err error) {
-	if f.data == nil {
-		return errWithoutVal(buffer.Write([]byte{'n', 'u', 'l', 'l'}))
+func (f StringerContent) AppendJSON(dst []byte) []byte {
+	switch data := f.data.(type) {
+	case nil:
+		return append(dst, "null"...)
+	case time.Duration:
+		dst = append(dst, '"')
+		return append(appendDuration(dst, data), '"')
+	}
+	if text, panicked := SafeString(f.data); panicked != nil {
+		return appendContentJSON(dst, panicked)
+	} else {
+		return appendString(dst, text, false)
 	}
-	return appendJsonStringBuf(buffer, fmt.Sprintf("%s", f.data))
+}
+
+func (f StringerContent) EncodeJSON(buffer Buffer) (err error) {
+	return errWithoutVal(buffer.Write(f.AppendJSON(nil)))
 }
 
 func Stringer(key string, val fmt.Stringer) Field {
@@ -633,6 +935,10 @@ func (f TimeContent) Data() any { return time.Time(f) }
 
 func (f TimeContent) Raw() time.Time { return time.Time(f) }
 
+func (f TimeContent) AppendJSON(dst []byte) []byte {
+	return appendTimeJSON(dst, time.Time(f), TimeText, "", false)
+}
+
 func (f TimeContent) EncodeJSON(buffer Buffer) error {
 	return appendJsonStringBuf(buffer, time.Time(f).Format(time.RFC3339))
 }
@@ -645,12 +951,99 @@ func Times(key string, valArr []time.Time) Field {
 	return Field{Key: key, Content: newArray(valArr, NewTimeContent)}
 }
 
+// data type: formatted time
+
+type TimeFormat uint8
+
+const (
+	// TimeText writes times as strings in a layout, time.RFC3339 if unset.
+	TimeText TimeFormat = iota
+	// TimeUnixSeconds writes times as exact decimal seconds since epoch.
+	TimeUnixSeconds
+	// TimeUnixMillis writes times as integer milliseconds since epoch.
+	TimeUnixMillis
+	// TimeUnixMicros writes times as integer microseconds since epoch.
+	TimeUnixMicros
+	// TimeUnixNanos writes times as integer nanoseconds since epoch.
+	TimeUnixNanos
+)
+
+// TimeFormatContent is a time carrying its own format, which takes
+// precedence over the time format of EncoderConfig.
+type TimeFormatContent struct {
+	data   time.Time
+	format TimeFormat
+	layout string
+}
+
+func NewTimeFormatContent(val time.Time, format TimeFormat, layout string) Content {
+	return TimeFormatContent{data: val, format: format, layout: layout}
+}
+
+func (f TimeFormatContent) Type() Type { return TypeTime }
+
+func (f TimeFormatContent) Data() any { return f.data }
+
+func (f TimeFormatContent) Raw() time.Time { return f.data }
+
+func (f TimeFormatContent) AppendJSON(dst []byte) []byte {
+	return appendTimeJSON(dst, f.data, f.format, f.layout, false)
+}
+
+func (f TimeFormatContent) EncodeJSON(buffer Buffer) error {
+	return errWithoutVal(buffer.Write(f.AppendJSON(nil)))
+}
+
+// TimeLayout makes a time field written in layout, like time.RFC3339Nano.
+func TimeLayout(key string, val time.Time, layout string) Field {
+	return Field{Key: key, Content: NewTimeFormatContent(val, TimeText, layout)}
+}
+
+// TimeUnix makes a time field written as a number of format since epoch.
+func TimeUnix(key string, val time.Time, format TimeFormat) Field {
+	return Field{Key: key, Content: NewTimeFormatContent(val, format, "")}
+}
+
 // data type: duration
 
 func Duration(key string, val time.Duration) Field { return Stringer(key, val) }
 
 func Durations(key string, valArr []time.Duration) Field { return Stringers(key, valArr) }
 
+// data type: map
+
+func Map[K comparable, V any](key string, m map[K]V) (f Field) {
+	defer recoverField(&f, key, m)
+	return mapField(key, m, 0)
+}
+
+func mapField[K comparable, V any](key string, m map[K]V, depth int) Field {
+	if m == nil {
+		return Nil(key)
+	}
+	if depth++; depth > maxReflectDepth {
+		return Error(key, fmt.Errorf("cant marshal field: exceeded max depth %d", maxReflectDepth))
+	}
+	var keyType = reflect.TypeOf(m).Key()
+	if keyType.Kind() == reflect.String {
+		// string keys cant collide, sorting by key is enough to hide the map
+		// iteration order from KeyInsertion
+		var fields = make(Fields, 0, len(m))
+		for k, v := range m {
+			fields = append(fields, anyField(reflect.ValueOf(k).String(), v, depth))
+		}
+		sort.Slice(fields, func(i, j int) bool { return fields[i].Key < fields[j].Key })
+		return Field{Key: key, Content: ObjectContent{fields: fields}}
+	}
+	var formatKey = mapKeyFormatter(keyType)
+	var entries = make([]mapEntry, 0, len(m))
+	for k, v := range m {
+		var keyVal = reflect.ValueOf(k)
+		entries = append(entries, mapEntry{key: keyVal, field: anyField(formatKey(keyVal), v, depth)})
+	}
+	return Field{Key: key, Content: ObjectContent{fields: sortMapEntries(entries)}}
+}
+
 func anyPointer[T any](key string, ptr *T, fn func(string, T) Field) Field {
 	if ptr == nil {
 		return Nil(key)
@@ -663,7 +1056,14 @@ type AnyTypeInterceptor interface {
 	Handle(reflectedType reflect.Type, val any) (Content, bool)
 }
 
-func Any(key string, val any) Field {
+// Any picks the constructor matching val, a panic of methods called on val
+// results in a placeholder content.
+func Any(key string, val any) (f Field) {
+	defer recoverField(&f, key, val)
+	return anyField(key, val, 0)
+}
+
+func anyField(key string, val any, depth int) Field {
 	switch v := val.(type) {
 	case nil:
 		return Nil(key)
@@ -781,12 +1181,27 @@ func Any(key string, val any) Field {
 		return anyPointer(key, v, Duration)
 	case []time.Duration:
 		return Durations(key, v)
-	case error:
-		return Error(key, v)
 	case *error:
 		return anyPointer(key, v, Error)
 	case []error:
 		return Errors(key, v)
+	case map[string]any:
+		return mapField(key, v, depth)
+	case map[string]string:
+		return mapField(key, v, depth)
+	case Fields:
+		return Object(key, v...)
+	case []Fields:
+		return Objects(key, v)
+	}
+	// interceptors come before the interfaces below, so they can override
+	// how a type implementing one of them is written
+	if content, ok := interceptAny(val); ok {
+		return Field{Key: key, Content: content}
+	}
+	switch v := val.(type) {
+	case error:
+		return Error(key, v)
 	case fmt.Stringer:
 		return Stringer(key, v)
 	case json.Marshaler:
@@ -808,5 +1223,8 @@ func Any(key string, val any) Field {
 			return Binary(key, content)
 		}
 	}
+	if encoder := typeEncoder(reflect.TypeOf(val)); encoder != nil {
+		return Field{Key: key, Content: encoder(reflect.ValueOf(val), depth)}
+	}
 	return Error(key, fmt.Errorf("cant marshal field: no type matched"))
 }
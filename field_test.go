@@ -414,7 +414,8 @@ func TestStringerField(t *testing.T) {
 			t.Error(fmt.Errorf("cant marshal stringer Field: %w", err))
 			return
 		}
-		var expected = fmt.Sprintf(`"case1":%q,"case2":[%q,%q],"case3":null,"case4":"<nil>"`, stringerA.val, stringerA.val, stringerB.val)
+		var expected = fmt.Sprintf(`"case1":%q,"case2":[%q,%q],"case3":null,"case4":{"!PANIC":%q,"type":"*field.testStringer"}`,
+			stringerA.val, stringerA.val, stringerB.val, "runtime error: invalid memory address or nil pointer dereference")
 		if result := buf.String(); result != expected {
 			t.Errorf("invalid marshal stringer result: `%v`, expected: `%s`", result, expected)
 			return
@@ -477,6 +478,39 @@ func TestTimeField(t *testing.T) {
 	})
 }
 
+func TestTimeFormatField(t *testing.T) {
+	var timeA = time.Date(2006, 1, 2, 15, 4, 5, 123456789, time.FixedZone("", 3600))
+	var timeB = time.Unix(-2, 250000000)
+	var tests = []struct {
+		name   string
+		field  Field
+		expect string
+	}{
+		{"layout", TimeLayout("k", timeA, time.RFC3339Nano), `"k":"2006-01-02T15:04:05.123456789+01:00"`},
+		{"layoutEscaped", TimeLayout("k", timeA, `"15"`), `"k":"\"15\""`},
+		{"seconds", TimeUnix("k", timeA, TimeUnixSeconds), `"k":1136210645.123456789`},
+		{"secondsWhole", TimeUnix("k", time.Unix(7, 0), TimeUnixSeconds), `"k":7`},
+		{"secondsNegative", TimeUnix("k", timeB, TimeUnixSeconds), `"k":-1.75`},
+		{"secondsNegativeFraction", TimeUnix("k", time.Unix(0, -5e8), TimeUnixSeconds), `"k":-0.5`},
+		{"millis", TimeUnix("k", timeA, TimeUnixMillis), `"k":1136210645123`},
+		{"micros", TimeUnix("k", timeA, TimeUnixMicros), `"k":1136210645123456`},
+		{"nanos", TimeUnix("k", timeA, TimeUnixNanos), `"k":1136210645123456789`},
+	}
+	for _, testItem := range tests {
+		t.Run(testItem.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := testItem.field.EncodeJSON(&buf); err != nil {
+				t.Error(fmt.Errorf("cant marshal time Field: %w", err))
+				return
+			}
+			if result := buf.String(); result != testItem.expect {
+				t.Errorf("invalid marshal time result: `%v`, expected: `%s`", result, testItem.expect)
+				return
+			}
+		})
+	}
+}
+
 func TestDurationField(t *testing.T) {
 	var durationA, durationB = time.Hour - time.Minute*16, time.Minute - time.Second*5
 	t.Run("newField", func(t *testing.T) {
@@ -664,6 +698,8 @@ func TestAnyField(t *testing.T) {
 		{"Any:PtrUintptr", Any("k", (*uintptr)(nil)), Nil("k")},
 		{"Any:PtrUintptr", Any("k", &uintptrVal), Uintptr("k", uintptrVal)},
 		{"Any:PtrError", Any("k", &errorVal), Error("k", errorVal)},
+		{"Any:Fields", Any("k", Fields{Bool("b", true)}), Object("k", Bool("b", true))},
+		{"Any:FieldsArr", Any("k", []Fields{{Bool("b", true)}}), Objects("k", []Fields{{Bool("b", true)}})},
 	}
 
 	for _, testItem := range tests {
@@ -683,3 +719,35 @@ func TestAnyField(t *testing.T) {
 		})
 	}
 }
+
+func TestObjectField(t *testing.T) {
+	t.Run("newField", func(t *testing.T) {
+		var expected = map[string]any{"method": "GET", "path": "/"}
+		if data := NewObjectContent(String("method", "GET"), String("path", "/")).Data(); !reflect.DeepEqual(data, expected) {
+			t.Errorf("invalid NewObjectContent response: %v", data)
+			return
+		}
+		if typ := NewObjectContent().Type(); typ != TypeObject {
+			t.Errorf("invalid NewObjectContent type: %v", typ)
+			return
+		}
+	})
+	t.Run("json", func(t *testing.T) {
+		var buf bytes.Buffer
+		var request = Object("request", String("path", "/"), String("method", "GET"), String("path", "/dup"))
+		if err := Object("http", request, Int("status", 200)).EncodeJSON(&buf); err != nil {
+			t.Error(fmt.Errorf("cant marshal object Field: %w", err))
+			return
+		}
+		buf.WriteByte(',')
+		if err := Objects("case2", []Fields{{Bool("b", true)}, {}}).EncodeJSON(&buf); err != nil {
+			t.Error(fmt.Errorf("cant marshal object Field: %w", err))
+			return
+		}
+		var expected = `"http":{"request":{"method":"GET","path":"/"},"status":200},"case2":[{"b":true},{}]`
+		if result := buf.String(); result != expected {
+			t.Errorf("invalid marshal object result: `%s`, expected: `%s`", result, expected)
+			return
+		}
+	})
+}
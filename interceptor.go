@@ -0,0 +1,77 @@
+package field
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// interceptors is consulted by Any when no builtin type matched, it holds an
+// immutable []AnyTypeInterceptor sorted by descending priority, so readers
+// never take a lock.
+var (
+	interceptors     atomic.Value
+	interceptorsLock sync.Mutex
+)
+
+func loadInterceptors() []AnyTypeInterceptor {
+	list, _ := interceptors.Load().([]AnyTypeInterceptor)
+	return list
+}
+
+// RegisterAnyTypeInterceptor adds interceptor to the registry used by Any.
+// Interceptors with higher Priority are consulted first, interceptors sharing
+// the same priority are consulted in order of registration.
+//
+// Any consults the registry after its builtin types, like int or time.Time,
+// and before the error, fmt.Stringer, json.Marshaler, encoding.TextMarshaler
+// and encoding.BinaryMarshaler interfaces and reflection, so an interceptor
+// can override how a type implementing one of them is written.
+func RegisterAnyTypeInterceptor(interceptor AnyTypeInterceptor) {
+	if interceptor == nil {
+		return
+	}
+	interceptorsLock.Lock()
+	defer interceptorsLock.Unlock()
+	var current = loadInterceptors()
+	var list = make([]AnyTypeInterceptor, len(current), len(current)+1)
+	copy(list, current)
+	list = append(list, interceptor)
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].Priority() > list[j].Priority()
+	})
+	interceptors.Store(list)
+}
+
+// UnregisterAnyTypeInterceptor removes every registration of interceptor,
+// which is matched by equality and thus must be of a comparable type.
+func UnregisterAnyTypeInterceptor(interceptor AnyTypeInterceptor) {
+	if interceptor == nil {
+		return
+	}
+	interceptorsLock.Lock()
+	defer interceptorsLock.Unlock()
+	var current = loadInterceptors()
+	var list = make([]AnyTypeInterceptor, 0, len(current))
+	for i := 0; i < len(current); i++ {
+		if current[i] != interceptor {
+			list = append(list, current[i])
+		}
+	}
+	interceptors.Store(list)
+}
+
+func interceptAny(val any) (Content, bool) {
+	var list = loadInterceptors()
+	if len(list) == 0 {
+		return nil, false
+	}
+	var reflectedType = reflect.TypeOf(val)
+	for i := 0; i < len(list); i++ {
+		if content, ok := list[i].Handle(reflectedType, val); ok && content != nil {
+			return content, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,128 @@
+package field
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+type chanTypeInterceptor struct{ priority uint }
+
+func (c chanTypeInterceptor) Priority() uint { return c.priority }
+
+func (c chanTypeInterceptor) Handle(reflectedType reflect.Type, val any) (Content, bool) {
+	if reflectedType.Kind() == reflect.Chan {
+		return NewStringContent(fmt.Sprintf("chan@%d", c.priority)), true
+	}
+	return nil, false
+}
+
+// stringerTypeInterceptor handles every value whose type has a String
+// method, builtin ones included.
+type stringerTypeInterceptor struct{}
+
+func (s stringerTypeInterceptor) Priority() uint { return 1 }
+
+func (s stringerTypeInterceptor) Handle(_ reflect.Type, val any) (Content, bool) {
+	if _, ok := val.(fmt.Stringer); ok {
+		return NewStringContent("intercepted"), true
+	}
+	return nil, false
+}
+
+type stringerError struct{}
+
+func (stringerError) Error() string { return "error" }
+
+func (stringerError) String() string { return "stringer" }
+
+func TestAnyTypeInterceptor(t *testing.T) {
+	var fn = func() {}
+	t.Run("unmatched", func(t *testing.T) {
+		if _, isErr := Any("k", fn).Content.(ErrorContent); !isErr {
+			t.Errorf("expected error content without interceptor")
+			return
+		}
+	})
+	t.Run("registered", func(t *testing.T) {
+		RegisterAnyTypeInterceptor(funcTypeInterceptor{})
+		defer UnregisterAnyTypeInterceptor(funcTypeInterceptor{})
+		var buf bytes.Buffer
+		if err := Any("k", fn).EncodeJSON(&buf); err != nil {
+			t.Error(err)
+			return
+		}
+		if expected := fmt.Sprintf(`"k":%q`, reflect.TypeOf(fn)); buf.String() != expected {
+			t.Errorf("invalid intercepted result: `%s`, expected: `%s`", buf.String(), expected)
+			return
+		}
+	})
+	t.Run("unregistered", func(t *testing.T) {
+		if _, isErr := Any("k", fn).Content.(ErrorContent); !isErr {
+			t.Errorf("expected error content after unregister")
+			return
+		}
+	})
+	t.Run("priority", func(t *testing.T) {
+		var low, high = chanTypeInterceptor{priority: 1}, chanTypeInterceptor{priority: 9}
+		RegisterAnyTypeInterceptor(low)
+		RegisterAnyTypeInterceptor(high)
+		defer UnregisterAnyTypeInterceptor(low)
+		defer UnregisterAnyTypeInterceptor(high)
+		if data := Any("k", make(chan int)).Data(); data != "chan@9" {
+			t.Errorf("invalid intercepted result: %v", data)
+			return
+		}
+		UnregisterAnyTypeInterceptor(high)
+		if data := Any("k", make(chan int)).Data(); data != "chan@1" {
+			t.Errorf("invalid intercepted result: %v", data)
+			return
+		}
+	})
+	t.Run("precedence", func(t *testing.T) {
+		RegisterAnyTypeInterceptor(stringerTypeInterceptor{})
+		defer UnregisterAnyTypeInterceptor(stringerTypeInterceptor{})
+		var tests = []struct {
+			name   string
+			val    any
+			expect string
+		}{
+			{"builtin", time.Second, "1s"},
+			{"interface", stringerError{}, "intercepted"},
+			{"pointer", &stringerError{}, "intercepted"},
+			{"unmatched", errors.New("boom"), "boom"},
+		}
+		for _, testItem := range tests {
+			t.Run(testItem.name, func(t *testing.T) {
+				if data := fmt.Sprint(Any("k", testItem.val).Data()); data != testItem.expect {
+					t.Errorf("invalid result: %v, expected: %v", data, testItem.expect)
+				}
+			})
+		}
+	})
+	t.Run("concurrent", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(2)
+			var interceptor = chanTypeInterceptor{priority: uint(i)}
+			go func() {
+				defer wg.Done()
+				RegisterAnyTypeInterceptor(interceptor)
+				UnregisterAnyTypeInterceptor(interceptor)
+			}()
+			go func() {
+				defer wg.Done()
+				_ = Any("k", make(chan int))
+			}()
+		}
+		wg.Wait()
+		if len(loadInterceptors()) != 0 {
+			t.Errorf("interceptors left after unregister: %v", loadInterceptors())
+			return
+		}
+	})
+}
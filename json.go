@@ -1,18 +1,29 @@
 package field
 
 import (
-	"bytes"
 	"encoding/json"
 	"errors"
+	"math"
+	"strconv"
+	"time"
 	"unicode/utf8"
-	_ "unsafe"
 )
 
-//go:linkname htmlSafeSet encoding/json.htmlSafeSet
-var htmlSafeSet [utf8.RuneSelf]bool
-
-//go:linkname safeSet encoding/json.safeSet
-var safeSet [utf8.RuneSelf]bool
+// safeSet holds the ASCII characters which can be written into a JSON string
+// without escaping, htmlSafeSet additionally excludes <, > and &. These mirror
+// the tables of encoding/json, which are not exported.
+var safeSet, htmlSafeSet = func() (safe, htmlSafe [utf8.RuneSelf]bool) {
+	for b := 0x20; b < utf8.RuneSelf; b++ {
+		switch b {
+		case '"', '\\':
+		case '<', '>', '&':
+			safe[b] = true
+		default:
+			safe[b], htmlSafe[b] = true, true
+		}
+	}
+	return safe, htmlSafe
+}()
 
 var hex = "0123456789abcdef"
 
@@ -84,35 +95,165 @@ func appendString[Bytes []byte | string](dst []byte, src Bytes, escapeHTML bool)
 	return dst
 }
 
+// appendTimeJSON appends t in format, text formats are written in layout
+// or time.RFC3339 and escaped.
+func appendTimeJSON(dst []byte, t time.Time, format TimeFormat, layout string, escapeHTML bool) []byte {
+	switch format {
+	case TimeUnixSeconds:
+		return appendUnixSeconds(dst, t)
+	case TimeUnixMillis:
+		return strconv.AppendInt(dst, t.UnixMilli(), 10)
+	case TimeUnixMicros:
+		return strconv.AppendInt(dst, t.UnixMicro(), 10)
+	case TimeUnixNanos:
+		return strconv.AppendInt(dst, t.UnixNano(), 10)
+	}
+	if layout == "" {
+		layout = time.RFC3339
+	}
+	var scratch [64]byte
+	return appendString(dst, t.AppendFormat(scratch[:0], layout), escapeHTML)
+}
+
+// appendUnixSeconds appends seconds of t since epoch with all the digits of
+// its nanoseconds, so no precision is lost to float64.
+func appendUnixSeconds(dst []byte, t time.Time) []byte {
+	var sec, nsec = t.Unix(), int64(t.Nanosecond())
+	if sec < 0 && nsec > 0 {
+		if sec, nsec = sec+1, 1e9-nsec; sec == 0 {
+			dst = append(dst, '-')
+		}
+	}
+	dst = strconv.AppendInt(dst, sec, 10)
+	if nsec == 0 {
+		return dst
+	}
+	var digits [10]byte
+	digits[0] = '.'
+	for i := 9; i > 0; i-- {
+		digits[i], nsec = byte('0'+nsec%10), nsec/10
+	}
+	var end = len(digits)
+	for digits[end-1] == '0' {
+		end--
+	}
+	return append(dst, digits[:end]...)
+}
+
+// appendFloatJSON appends f as a number, or quoted if it is not finite.
+func appendFloatJSON(dst []byte, f float64, fmt byte, bitSize int) []byte {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		dst = append(dst, '"')
+		return append(strconv.AppendFloat(dst, f, fmt, -1, bitSize), '"')
+	}
+	return strconv.AppendFloat(dst, f, fmt, -1, bitSize)
+}
+
+// appendComplexJSON appends c quoted like strconv.FormatComplex formats it,
+// bitSize is the size of its parts.
+func appendComplexJSON(dst []byte, c complex128, fmt byte, bitSize int) []byte {
+	dst = append(dst, '"', '(')
+	dst = strconv.AppendFloat(dst, real(c), fmt, -1, bitSize)
+	var n = len(dst)
+	dst = strconv.AppendFloat(dst, imag(c), fmt, -1, bitSize)
+	if dst[n] != '+' && dst[n] != '-' {
+		dst = append(dst, 0)
+		copy(dst[n+1:], dst[n:])
+		dst[n] = '+'
+	}
+	return append(dst, 'i', ')', '"')
+}
+
+// appendDuration appends d formatted like time.Duration.String.
+func appendDuration(dst []byte, d time.Duration) []byte {
+	var u = uint64(d)
+	if d < 0 {
+		dst, u = append(dst, '-'), -u
+	}
+	if u < uint64(time.Second) {
+		switch {
+		case u == 0:
+			return append(dst, '0', 's')
+		case u < uint64(time.Microsecond):
+			return append(strconv.AppendUint(dst, u, 10), 'n', 's')
+		case u < uint64(time.Millisecond):
+			return append(appendDecimal(dst, u, 3), "µs"...)
+		default:
+			return append(appendDecimal(dst, u, 6), 'm', 's')
+		}
+	}
+	var hours, minutes = u / uint64(time.Hour), u / uint64(time.Minute) % 60
+	if hours > 0 {
+		dst = append(strconv.AppendUint(dst, hours, 10), 'h')
+	}
+	if hours > 0 || minutes > 0 {
+		dst = append(strconv.AppendUint(dst, minutes, 10), 'm')
+	}
+	return append(appendDecimal(dst, u%uint64(time.Minute), 9), 's')
+}
+
+// appendDecimal appends v/10**prec, omitting trailing zeros of the fraction.
+func appendDecimal(dst []byte, v uint64, prec int) []byte {
+	var pow uint64 = 1
+	for i := 0; i < prec; i++ {
+		pow *= 10
+	}
+	dst = strconv.AppendUint(dst, v/pow, 10)
+	if v %= pow; v == 0 {
+		return dst
+	}
+	dst = append(dst, '.')
+	for pow /= 10; v > 0; pow /= 10 {
+		dst = append(dst, byte('0'+v/pow))
+		v %= pow
+	}
+	return dst
+}
+
 func appendJsonStringBuf(w Buffer, s string) (err error) {
 	var _, wrote = w.Write(appendString[string](nil, s, false))
 	return wrote
 }
 
-type jsonErr interface {
-	error
-	json.Marshaler
+// sliceBuffer is a Buffer appending to data, it lets contents without
+// AppendJSON write into a caller provided slice.
+type sliceBuffer struct{ data []byte }
+
+func (b *sliceBuffer) Write(p []byte) (int, error) {
+	b.data = append(b.data, p...)
+	return len(p), nil
 }
 
-func asJsonErrMarshaler(err error) (jsonErr jsonErr) {
-	if err == nil {
-		return nil
-	}
-	if errors.As(err, &jsonErr) {
-		return jsonErr
-	}
-	return jsonErrMarshaler{err}
+func (b *sliceBuffer) WriteString(s string) (int, error) {
+	b.data = append(b.data, s...)
+	return len(s), nil
 }
 
-type jsonErrMarshaler struct{ error }
+func (b *sliceBuffer) WriteByte(c byte) error {
+	b.data = append(b.data, c)
+	return nil
+}
+
+func (b *sliceBuffer) WriteRune(r rune) (int, error) {
+	var n = len(b.data)
+	b.data = utf8.AppendRune(b.data, r)
+	return len(b.data) - n, nil
+}
 
-func (e jsonErrMarshaler) MarshalJSON() ([]byte, error) {
-	if e.error == nil {
-		return []byte("null"), nil
+// asJSONMarshaler finds the first json.Marshaler in the chain of err, leaf
+// errors are checked without errors.As which allocates.
+func asJSONMarshaler(err error) (json.Marshaler, bool) {
+	if marshaler, ok := err.(json.Marshaler); ok {
+		return marshaler, true
 	}
-	var buffer bytes.Buffer
-	if err := appendJsonStringBuf(&buffer, e.Error()); err != nil {
-		return nil, err
+	switch err.(type) {
+	case interface{ Unwrap() error }, interface{ Unwrap() []error }, interface{ As(any) bool }:
+		return findJSONMarshaler(err)
 	}
-	return buffer.Bytes(), nil
+	return nil, false
+}
+
+func findJSONMarshaler(err error) (marshaler json.Marshaler, ok bool) {
+	ok = errors.As(err, &marshaler)
+	return marshaler, ok
 }
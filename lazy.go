@@ -0,0 +1,65 @@
+package field
+
+import "sync"
+
+// LazyContent defers building its content until first used, so fields of
+// records dropped by a logger cost nothing but a closure. The content is
+// built once and shared by copies, methods are safe for concurrent use.
+type LazyContent struct {
+	state *lazyState
+}
+
+type lazyState struct {
+	once    sync.Once
+	build   func() Content
+	content Content
+}
+
+// NewLazyContent wraps build, which is called at most once. A panic of build
+// results in a placeholder content.
+func NewLazyContent(build func() Content) Content {
+	return LazyContent{state: &lazyState{build: build}}
+}
+
+// Raw builds the content if not done yet and returns it, never nil.
+func (f LazyContent) Raw() Content {
+	if f.state == nil {
+		return NilContent{}
+	}
+	f.state.once.Do(f.state.resolve)
+	return f.state.content
+}
+
+func (s *lazyState) resolve() {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			s.content = panicContent(s.build, recovered)
+		}
+		s.build = nil
+	}()
+	if s.build != nil {
+		s.content = s.build()
+	}
+	if s.content == nil {
+		s.content = NilContent{}
+	}
+}
+
+func (f LazyContent) Type() Type { return f.Raw().Type() }
+
+func (f LazyContent) Data() any { return f.Raw().Data() }
+
+func (f LazyContent) AppendJSON(dst []byte) []byte { return appendContentJSON(dst, f.Raw()) }
+
+func (f LazyContent) EncodeJSON(buffer Buffer) error { return f.Raw().EncodeJSON(buffer) }
+
+func (f LazyContent) EncodeLogfmt(buffer Buffer) error { return encodeLogfmtContent(buffer, f.Raw()) }
+
+func Lazy(key string, build func() Content) Field {
+	return Field{Key: key, Content: NewLazyContent(build)}
+}
+
+// LazyAny is Lazy converting the value of build like Any does.
+func LazyAny(key string, build func() any) Field {
+	return Lazy(key, func() Content { return Any(key, build()).Content })
+}
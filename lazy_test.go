@@ -0,0 +1,65 @@
+package field
+
+import (
+	"bytes"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestLazyField(t *testing.T) {
+	t.Run("once", func(t *testing.T) {
+		var calls int32
+		var lazy = LazyAny("state", func() any {
+			atomic.AddInt32(&calls, 1)
+			return []int{1, 2}
+		})
+		if atomic.LoadInt32(&calls) != 0 {
+			t.Errorf("invalid calls before use: %d", calls)
+			return
+		}
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				var buf bytes.Buffer
+				if err := lazy.EncodeJSON(&buf); err != nil || buf.String() != `"state":[1,2]` {
+					t.Errorf("invalid json: %s, %v", buf.String(), err)
+				}
+			}()
+		}
+		wg.Wait()
+		if lazy.Type() != TypeArray|TypeInt || atomic.LoadInt32(&calls) != 1 {
+			t.Errorf("invalid lazy: type %v, calls %d", lazy.Type(), calls)
+			return
+		}
+	})
+	var tests = []struct {
+		name   string
+		field  Field
+		expect string
+	}{
+		{"content", Lazy("k", func() Content { return NewBinaryContent([]byte{1}) }), `{"k":"01"}`},
+		{"object", LazyAny("k", func() any { return map[string]int{"a": 1} }), `{"k":{"a":1}}`},
+		{"nil", Lazy("k", func() Content { return nil }), `{"k":null}`},
+		{"nilFunc", Lazy("k", nil), `{"k":null}`},
+		{"panic", Lazy("k", func() Content { panic("boom") }), `{"k":{"!PANIC":"boom","type":"func() field.Content"}}`},
+	}
+	for _, testItem := range tests {
+		t.Run(testItem.name, func(t *testing.T) {
+			var result, err = EncoderConfig{Binary: BinaryHex}.AppendJSON(nil, Fields{testItem.field})
+			if err != nil || string(result) != testItem.expect {
+				t.Errorf("invalid json: %s, expected: %s, %v", result, testItem.expect, err)
+				return
+			}
+		})
+	}
+	t.Run("logfmt", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := (Fields{LazyAny("k", func() any { return "a b" })}).EncodeLogfmt(&buf); err != nil || buf.String() != `k="a b"` {
+			t.Errorf("invalid logfmt: %s, %v", buf.String(), err)
+			return
+		}
+	})
+}
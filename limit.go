@@ -0,0 +1,240 @@
+package field
+
+import (
+	"strconv"
+	"sync/atomic"
+	"unicode/utf8"
+)
+
+// truncatedKey is the key of the field counting fields dropped for MaxTotal.
+const truncatedKey = "!TRUNCATED"
+
+// SizeLimits bounds values on encoding, zero means unlimited.
+type SizeLimits struct {
+	// MaxString is the byte limit of strings, including the text of errors
+	// and stringers. Longer ones are cut on a rune boundary and end with a
+	// marker like "…(+120 bytes)".
+	MaxString int
+	// MaxBinary is the byte limit of binaries, longer ones are cut without
+	// a marker, which would break their encoding.
+	MaxBinary int
+	// MaxArray is the element limit of arrays, a string like "…(+20 items)"
+	// is added as the last element of longer ones.
+	MaxArray int
+	// MaxTotal is the byte limit of an encoded Fields in every encoding.
+	// Fields that dont fit are dropped from the end and counted by a
+	// "!TRUNCATED" field, nested objects share the bytes left for their
+	// field; the object holding just the count may still exceed a tiny limit.
+	MaxTotal int
+}
+
+var sizeLimits atomic.Value
+
+// SetSizeLimits installs limits for the encoders of this package,
+// EncoderConfig with zero Limits included.
+func SetSizeLimits(limits SizeLimits) {
+	sizeLimits.Store(limits)
+}
+
+func loadSizeLimits() SizeLimits {
+	limits, _ := sizeLimits.Load().(SizeLimits)
+	return limits
+}
+
+// limitFields limits contents of fields in place, nested objects are limited
+// when encoded themselves.
+func (l SizeLimits) limitFields(fields []Field) []Field {
+	if !l.limitsContent() {
+		return fields
+	}
+	for i := 0; i < len(fields); i++ {
+		fields[i].Content = l.limitContent(fields[i].Content)
+	}
+	return fields
+}
+
+func (l SizeLimits) limitField(f Field) Field {
+	if l.limitsContent() {
+		f.Content = l.limitContent(f.Content)
+	}
+	return f
+}
+
+// limitsContent reports whether l bounds any value, MaxTotal aside.
+func (l SizeLimits) limitsContent() bool {
+	return l.MaxString > 0 || l.MaxBinary > 0 || l.MaxArray > 0
+}
+
+func (l SizeLimits) limitContent(content Content) Content {
+	switch v := content.(type) {
+	case LazyContent:
+		return l.limitContent(v.Raw())
+	case StringContent:
+		if l.MaxString > 0 && len(v) > l.MaxString {
+			return StringContent(truncateString(string(v), l.MaxString))
+		}
+	case ErrorContent:
+		if l.MaxString > 0 && v.data != nil {
+			if text, panicked := SafeError(v.data); panicked == nil && len(text) > l.MaxString {
+				return StringContent(truncateString(text, l.MaxString))
+			}
+		}
+	case StringerContent:
+		if l.MaxString > 0 && v.data != nil {
+			if text, panicked := SafeString(v.data); panicked == nil && len(text) > l.MaxString {
+				return StringContent(truncateString(text, l.MaxString))
+			}
+		}
+	case BinaryContent:
+		if l.MaxBinary > 0 && len(v.binaryRaw) > l.MaxBinary {
+			return BinaryContent{binaryRaw: v.binaryRaw[:l.MaxBinary]}
+		}
+	case ArrayContent:
+		var list = v.arrayRaw
+		var dropped = 0
+		if l.MaxArray > 0 && len(list) > l.MaxArray {
+			list, dropped = list[:l.MaxArray], len(list)-l.MaxArray
+		}
+		var limited = make([]Content, len(list), len(list)+1)
+		for i := 0; i < len(list); i++ {
+			limited[i] = l.limitContent(list[i])
+		}
+		if dropped > 0 {
+			limited = append(limited, StringContent("…(+"+strconv.Itoa(dropped)+" items)"))
+		}
+		return ArrayContent{arrayRaw: limited}
+	}
+	return content
+}
+
+// truncateString cuts s to at most max bytes on a rune boundary and appends
+// a marker counting the bytes cut.
+func truncateString(s string, max int) string {
+	var cut = max
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + "…(+" + strconv.Itoa(len(s)-cut) + " bytes)"
+}
+
+// entryEncoder writes the fields of an object for appendLimited.
+type entryEncoder struct {
+	// sep is written between entries.
+	sep string
+	// appendField appends f, budget is the count of bytes left for it which
+	// nested objects must not exceed, zero if unlimited.
+	appendField func(dst []byte, f Field, budget int) ([]byte, error)
+	// appendMarker appends the entry counting dropped fields.
+	appendMarker func(dst []byte, dropped int) []byte
+}
+
+// appendLimited appends snap by enc in at most budget bytes. Fields are
+// dropped from the end until the kept ones fit along with the marker counting
+// the others, a field which cant fit is dropped before being encoded. count is
+// the number of entries appended, the marker included.
+func appendLimited(dst []byte, snap []Field, budget int, enc entryEncoder) (_ []byte, count int, err error) {
+	var start = len(dst)
+	var ends = make([]int, 0, len(snap))
+	for i := 0; i < len(snap); i++ {
+		var mark = len(dst)
+		if len(dst) > start {
+			dst = append(dst, enc.sep...)
+		}
+		var left = budget - (len(dst) - start)
+		if left < len(snap[i].Key)+minContentLen(snap[i].Content) {
+			dst = dst[:mark]
+			break
+		}
+		var entryStart = len(dst)
+		if dst, err = enc.appendField(dst, snap[i], left); err != nil {
+			return dst[:start], 0, err
+		}
+		if len(dst)-start > budget {
+			dst = dst[:mark]
+			break
+		}
+		if len(dst) == entryStart {
+			// nothing written, like an empty object flattened in logfmt
+			dst = dst[:mark]
+		}
+		ends = append(ends, len(dst))
+	}
+	if len(ends) == len(snap) {
+		return dst, len(ends), nil
+	}
+	// keep the most fields which fit along with the count of the others
+	var kept = len(ends)
+	for ; kept > 0; kept-- {
+		var markerLen = len(enc.appendMarker(nil, len(snap)-kept))
+		if ends[kept-1] > start {
+			markerLen += len(enc.sep)
+		}
+		if ends[kept-1]-start+markerLen <= budget {
+			break
+		}
+	}
+	dst = dst[:start]
+	if kept > 0 {
+		dst = dst[:ends[kept-1]]
+	}
+	if len(dst) > start {
+		dst = append(dst, enc.sep...)
+	}
+	return enc.appendMarker(dst, len(snap)-kept), kept + 1, nil
+}
+
+// minContentLen is a lower bound of the bytes any encoder of this package
+// writes for content, it spares encoding values which cant fit.
+func minContentLen(content Content) int {
+	switch v := content.(type) {
+	case StringContent:
+		return len(v)
+	case BinaryContent:
+		return len(v.binaryRaw)
+	case ArrayContent:
+		return len(v.arrayRaw)
+	}
+	return 1
+}
+
+// nestedBudget returns budget less the used bytes for values nested in a
+// field, at least 1 so they stay limited, and 0 if budget is unlimited.
+func nestedBudget(budget, used int) int {
+	switch {
+	case budget <= 0:
+		return 0
+	case budget-used < 1:
+		return 1
+	}
+	return budget - used
+}
+
+// appendFieldsLimited appends snap as a JSON object by appendField, if
+// maxTotal is positive the object is limited to it by appendLimited with a
+// "!TRUNCATED" count.
+func appendFieldsLimited(dst []byte, snap []Field, maxTotal int, appendField func([]byte, Field, int) ([]byte, error)) (_ []byte, err error) {
+	var start = len(dst)
+	dst = append(dst, '{')
+	if maxTotal > 0 {
+		var enc = entryEncoder{sep: ",", appendField: appendField, appendMarker: appendJSONMarker}
+		if dst, _, err = appendLimited(dst, snap, maxTotal-len("{}"), enc); err != nil {
+			return dst[:start], err
+		}
+		return append(dst, '}'), nil
+	}
+	for i := 0; i < len(snap); i++ {
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+		if dst, err = appendField(dst, snap[i], 0); err != nil {
+			return dst[:start], err
+		}
+	}
+	return append(dst, '}'), nil
+}
+
+func appendJSONMarker(dst []byte, dropped int) []byte {
+	dst = appendString(dst, truncatedKey, false)
+	dst = append(dst, ':')
+	return strconv.AppendInt(dst, int64(dropped), 10)
+}
@@ -0,0 +1,158 @@
+package field
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSizeLimits(t *testing.T) {
+	var fields = Fields{String("a", "xxxx"), String("b", "yyyy"), String("c", "zzzz")}
+	var tests = []struct {
+		name   string
+		limits SizeLimits
+		fields Fields
+		expect string
+	}{
+		{"string", SizeLimits{MaxString: 2}, Fields{String("k", "héllo wörld")}, `{"k":"h…(+12 bytes)"}`},
+		{"stringFits", SizeLimits{MaxString: 5}, Fields{String("k", "hello")}, `{"k":"hello"}`},
+		{"error", SizeLimits{MaxString: 4}, Fields{Error("k", errors.New("boom!"))}, `{"k":"boom…(+1 bytes)"}`},
+		{"binary", SizeLimits{MaxBinary: 2}, Fields{Binary("k", []byte{1, 2, 3})}, `{"k":"data:;base64,AQI"}`},
+		{"array", SizeLimits{MaxArray: 2}, Fields{Ints("k", []int{1, 2, 3, 4})}, `{"k":[1,2,"…(+2 items)"]}`},
+		{"nested", SizeLimits{MaxString: 1, MaxArray: 1}, Fields{Object("k", Strings("a", []string{"xy", "z"}))}, `{"k":{"a":["x…(+1 bytes)","…(+1 items)"]}}`},
+		{"totalFits", SizeLimits{MaxTotal: 35}, fields, `{"a":"xxxx","b":"yyyy","c":"zzzz"}`},
+		{"total", SizeLimits{MaxTotal: 30}, fields, `{"a":"xxxx","!TRUNCATED":2}`},
+		{"totalTiny", SizeLimits{MaxTotal: 5}, fields, `{"!TRUNCATED":3}`},
+		{"totalNested", SizeLimits{MaxTotal: 35}, Fields{Object("k", fields...)}, `{"k":{"a":"xxxx","!TRUNCATED":2}}`},
+		{"totalNestedArray", SizeLimits{MaxTotal: 37}, Fields{Objects("k", []Fields{fields})}, `{"k":[{"a":"xxxx","!TRUNCATED":2}]}`},
+		{"totalArrayShared", SizeLimits{MaxTotal: 59}, Fields{Objects("k", []Fields{fields, fields})}, `{"k":[{"a":"xxxx","b":"yyyy","c":"zzzz"},{"!TRUNCATED":3}]}`},
+	}
+	for _, testItem := range tests {
+		t.Run(testItem.name, func(t *testing.T) {
+			var result, err = EncoderConfig{Limits: testItem.limits}.AppendJSON(nil, testItem.fields)
+			if err != nil || string(result) != testItem.expect {
+				t.Errorf("invalid json: %s, expected: %s, %v", result, testItem.expect, err)
+				return
+			}
+			SetSizeLimits(testItem.limits)
+			defer SetSizeLimits(SizeLimits{})
+			if result = testItem.fields.AppendJSON(nil); string(result) != testItem.expect {
+				t.Errorf("invalid appended json: %s, expected: %s", result, testItem.expect)
+				return
+			}
+			var buf bytes.Buffer
+			if err = testItem.fields.EncodeJSON(&buf); err != nil || buf.String() != testItem.expect {
+				t.Errorf("invalid encoded json: %s, expected: %s, %v", buf.String(), testItem.expect, err)
+				return
+			}
+		})
+	}
+	t.Run("logfmt", func(t *testing.T) {
+		SetSizeLimits(SizeLimits{MaxString: 3, MaxArray: 1})
+		defer SetSizeLimits(SizeLimits{})
+		var buf bytes.Buffer
+		if err := (Fields{String("a", "abcdef"), Ints("b", []int{1, 2})}).EncodeLogfmt(&buf); err != nil {
+			t.Errorf("cant encode logfmt: %v", err)
+			return
+		}
+		if expected := `a="abc…(+3 bytes)" b="[1,\"…(+1 items)\"]"`; buf.String() != expected {
+			t.Errorf("invalid logfmt: %s, expected: %s", buf.String(), expected)
+			return
+		}
+	})
+	t.Run("totalEncoders", func(t *testing.T) {
+		var nested = Fields{Object("k", fields...)}
+		var objects = Objects("k", []Fields{fields, fields})
+		var long = String("k", strings.Repeat("x", 100))
+		var tests = []struct {
+			name   string
+			total  int
+			encode func() ([]byte, error)
+			expect string
+		}{
+			{"logfmt", 19, fields.MarshalLogfmt, `a=xxxx !TRUNCATED=2`},
+			{"logfmtFits", 20, fields.MarshalLogfmt, `a=xxxx b=yyyy c=zzzz`},
+			{"logfmtNested", 25, nested.MarshalLogfmt, `k.a=xxxx k.!TRUNCATED=2`},
+			{"console", 19, func() ([]byte, error) {
+				var buf bytes.Buffer
+				var err = fields.EncodeConsole(&buf)
+				return buf.Bytes(), err
+			}, `a=xxxx !TRUNCATED=2`},
+			{"consoleNested", 23, func() ([]byte, error) {
+				var buf bytes.Buffer
+				var err = nested.EncodeConsole(&buf)
+				return buf.Bytes(), err
+			}, `k={a=xxxx !TRUNCATED=2}`},
+			{"field", 33, nested[0].MarshalJSON, `{"k":{"a":"xxxx","!TRUNCATED":2}}`},
+			{"fieldEncode", 31, func() ([]byte, error) {
+				var buf bytes.Buffer
+				var err = nested[0].EncodeJSON(&buf)
+				return buf.Bytes(), err
+			}, `"k":{"a":"xxxx","!TRUNCATED":2}`},
+			{"fieldLong", 20, long.MarshalJSON, `{"!TRUNCATED":1}`},
+			{"fieldLongEncode", 20, func() ([]byte, error) {
+				var buf bytes.Buffer
+				var err = long.EncodeJSON(&buf)
+				return buf.Bytes(), err
+			}, `"!TRUNCATED":1`},
+			{"fieldLongAppend", 20, func() ([]byte, error) { return long.AppendJSON(nil), nil }, `"!TRUNCATED":1`},
+			{"arrayObjects", 53, func() ([]byte, error) {
+				var buf bytes.Buffer
+				var err = objects.Content.EncodeJSON(&buf)
+				return buf.Bytes(), err
+			}, `[{"a":"xxxx","b":"yyyy","c":"zzzz"},{"!TRUNCATED":3}]`},
+			{"arrayObjectsAppend", 53, func() ([]byte, error) { return objects.Content.(ArrayContent).AppendJSON(nil), nil }, `[{"a":"xxxx","b":"yyyy","c":"zzzz"},{"!TRUNCATED":3}]`},
+			{"cbor", 20, fields.MarshalCBOR, `{"!TRUNCATED":2,"a":"xxxx"}`},
+			{"msgpack", 20, fields.MarshalMsgpack, `{"!TRUNCATED":2,"a":"xxxx"}`},
+		}
+		for _, testItem := range tests {
+			t.Run(testItem.name, func(t *testing.T) {
+				SetSizeLimits(SizeLimits{MaxTotal: testItem.total})
+				var result, err = testItem.encode()
+				SetSizeLimits(SizeLimits{})
+				if err != nil || len(result) > testItem.total {
+					t.Errorf("invalid result: %q of %d bytes, %v", result, len(result), err)
+					return
+				}
+				var decoded Fields
+				switch testItem.name {
+				case "cbor":
+					err = decoded.UnmarshalCBOR(result)
+				case "msgpack":
+					err = decoded.UnmarshalMsgpack(result)
+				}
+				if decoded != nil {
+					if result, err = decoded.MarshalJSON(); err != nil {
+						t.Errorf("cant encode decoded fields: %v", err)
+						return
+					}
+				}
+				if string(result) != testItem.expect {
+					t.Errorf("invalid result: %s, expected: %s, %v", result, testItem.expect, err)
+					return
+				}
+			})
+		}
+	})
+	t.Run("totalStops", func(t *testing.T) {
+		SetSizeLimits(SizeLimits{MaxTotal: 20})
+		defer SetSizeLimits(SizeLimits{})
+		var built = false
+		var lazy = Lazy("z", func() Content { built = true; return StringContent("z") })
+		var result = Fields{String("a", strings.Repeat("x", 100)), lazy}.AppendJSON(nil)
+		if expected := `{"!TRUNCATED":2}`; string(result) != expected || built {
+			t.Errorf("invalid json: %s, expected: %s, lazy built: %v", result, expected, built)
+			return
+		}
+	})
+	t.Run("override", func(t *testing.T) {
+		SetSizeLimits(SizeLimits{MaxString: 1})
+		defer SetSizeLimits(SizeLimits{})
+		var result, err = EncoderConfig{Limits: SizeLimits{MaxString: 3}}.AppendJSON(nil, Fields{String("k", "abcd")})
+		if expected := `{"k":"abc…(+1 bytes)"}`; err != nil || string(result) != expected {
+			t.Errorf("invalid json: %s, expected: %s, %v", result, expected, err)
+			return
+		}
+	})
+}
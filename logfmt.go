@@ -0,0 +1,290 @@
+package field
+
+import (
+	"bytes"
+	"strconv"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// logfmtMarshaler is implemented by contents which can be written as value of
+// a logfmt pair, contents without it are written as their JSON text.
+type logfmtMarshaler interface {
+	EncodeLogfmt(buffer Buffer) error
+}
+
+// EncodeLogfmt writes fields as space separated key=value pairs, members of
+// nested objects are flattened with dotted keys.
+func (f Fields) EncodeLogfmt(buf Buffer) error {
+	if maxTotal := loadSizeLimits().MaxTotal; maxTotal > 0 {
+		var data, err = appendLogfmtLimited(nil, "", f.Snapshot(), maxTotal)
+		if err != nil {
+			return err
+		}
+		return errWithoutVal(buf.Write(data))
+	}
+	var _, err = encodeLogfmtFields(buf, "", f, false)
+	return err
+}
+
+func (f Fields) MarshalLogfmt() (dst []byte, err error) {
+	var buf bytes.Buffer
+	err = f.EncodeLogfmt(&buf)
+	return buf.Bytes(), err
+}
+
+func (f Field) EncodeLogfmt(buffer Buffer) error {
+	if maxTotal := loadSizeLimits().MaxTotal; maxTotal > 0 {
+		var data, err = appendLogfmtLimited(nil, "", []Field{f.snapshot()}, maxTotal)
+		if err != nil {
+			return err
+		}
+		return errWithoutVal(buffer.Write(data))
+	}
+	var _, err = encodeLogfmtField(buffer, "", f.snapshot(), false)
+	return err
+}
+
+func encodeLogfmtFields(buf Buffer, prefix string, fields Fields, wrote bool) (_ bool, err error) {
+	var snap = fields.Snapshot()
+	for i := 0; i < len(snap); i++ {
+		if wrote, err = encodeLogfmtField(buf, prefix, snap[i], wrote); err != nil {
+			return wrote, err
+		}
+	}
+	return wrote, nil
+}
+
+// appendLogfmtLimited appends snap flattened under prefix in at most budget
+// bytes, fields over it are dropped for a count keyed prefix+"!TRUNCATED".
+func appendLogfmtLimited(dst []byte, prefix string, snap []Field, budget int) (_ []byte, err error) {
+	dst, _, err = appendLimited(dst, snap, budget, entryEncoder{
+		sep: " ",
+		appendField: func(dst []byte, f Field, budget int) ([]byte, error) {
+			if lazy, ok := f.Content.(LazyContent); ok {
+				f.Content = lazy.Raw()
+			}
+			if object, ok := f.Content.(ObjectContent); ok {
+				return appendLogfmtLimited(dst, prefix+f.Key+".", object.fields.Snapshot(), budget)
+			}
+			var buf = sliceBuffer{data: dst}
+			var _, err = encodeLogfmtField(&buf, prefix, f, false)
+			return buf.data, err
+		},
+		appendMarker: func(dst []byte, dropped int) []byte {
+			var buf = sliceBuffer{data: dst}
+			_ = writeLogfmtKey(&buf, prefix+truncatedKey)
+			return strconv.AppendInt(append(buf.data, '='), int64(dropped), 10)
+		},
+	})
+	return dst, err
+}
+
+func encodeLogfmtField(buf Buffer, prefix string, f Field, wrote bool) (_ bool, err error) {
+	if lazy, ok := f.Content.(LazyContent); ok {
+		f.Content = lazy.Raw()
+	}
+	if object, ok := f.Content.(ObjectContent); ok {
+		return encodeLogfmtFields(buf, prefix+f.Key+".", object.fields, wrote)
+	}
+	if wrote {
+		if err = buf.WriteByte(' '); err != nil {
+			return wrote, err
+		}
+	}
+	if err = writeLogfmtKey(buf, prefix+f.Key); err != nil {
+		return true, err
+	}
+	if err = buf.WriteByte('='); err != nil {
+		return true, err
+	}
+	return true, encodeLogfmtContent(buf, f.Content)
+}
+
+func encodeLogfmtContent(buf Buffer, content Content) error {
+	if content == nil {
+		return NilContent{}.EncodeLogfmt(buf)
+	}
+	if marshaler, ok := content.(logfmtMarshaler); ok {
+		return marshaler.EncodeLogfmt(buf)
+	}
+	var jsonBuf bytes.Buffer
+	if err := content.EncodeJSON(&jsonBuf); err != nil {
+		return err
+	}
+	return writeLogfmtValue(buf, jsonBuf.Bytes())
+}
+
+// writeLogfmtKey writes key with bytes that would break the pair replaced.
+func writeLogfmtKey(buf Buffer, key string) (err error) {
+	if key == "" {
+		return buf.WriteByte('_')
+	}
+	for i := 0; i < len(key); i++ {
+		var b = key[i]
+		if b <= ' ' || b == '=' || b == '"' || b == utf8.RuneSelf-1 {
+			b = '_'
+		}
+		if err = buf.WriteByte(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func logfmtNeedsQuote[Bytes []byte | string](s Bytes) bool {
+	if len(s) == 0 {
+		return true
+	}
+	for i := 0; i < len(s); {
+		if b := s[i]; b < utf8.RuneSelf {
+			if b <= ' ' || b == '=' || b == '"' || b == '\\' || b == utf8.RuneSelf-1 {
+				return true
+			}
+			i++
+			continue
+		}
+		var n = len(s) - i
+		if n > utf8.UTFMax {
+			n = utf8.UTFMax
+		}
+		var c, size = utf8.DecodeRuneInString(string(s[i : i+n]))
+		if c == utf8.RuneError || !unicode.IsPrint(c) {
+			return true
+		}
+		i += size
+	}
+	return false
+}
+
+// writeLogfmtValue writes s as is, or quoted and escaped like a JSON string
+// if it were empty or contains bytes that would break the pair.
+func writeLogfmtValue[Bytes []byte | string](buf Buffer, s Bytes) error {
+	if logfmtNeedsQuote(s) {
+		return errWithoutVal(buf.Write(appendString(nil, s, false)))
+	}
+	return errWithoutVal(buf.Write([]byte(s)))
+}
+
+func (f ArrayContent) EncodeLogfmt(buffer Buffer) error {
+	var textBuf bytes.Buffer
+	if err := f.appendLogfmtText(&textBuf); err != nil {
+		return err
+	}
+	return writeLogfmtValue(buffer, textBuf.Bytes())
+}
+
+// appendLogfmtText writes elements unquoted in brackets, the whole text is
+// quoted once by the outermost container.
+func (f ArrayContent) appendLogfmtText(buf *bytes.Buffer) (err error) {
+	buf.WriteByte('[')
+	for i := 0; i < len(f.arrayRaw); i++ {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		switch elem := f.arrayRaw[i].(type) {
+		case ArrayContent:
+			err = elem.appendLogfmtText(buf)
+		case ObjectContent:
+			err = elem.appendLogfmtText(buf)
+		default:
+			err = encodeLogfmtContent(buf, elem)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	buf.WriteByte(']')
+	return nil
+}
+
+func (f ObjectContent) EncodeLogfmt(buffer Buffer) error {
+	var textBuf bytes.Buffer
+	if err := f.appendLogfmtText(&textBuf); err != nil {
+		return err
+	}
+	return writeLogfmtValue(buffer, textBuf.Bytes())
+}
+
+func (f ObjectContent) appendLogfmtText(buf *bytes.Buffer) (err error) {
+	buf.WriteByte('{')
+	if _, err = encodeLogfmtFields(buf, "", f.fields, false); err != nil {
+		return err
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+func (n NilContent) EncodeLogfmt(buffer Buffer) error { return n.EncodeJSON(buffer) }
+
+func (f JSONContent) EncodeLogfmt(buffer Buffer) error {
+	return writeLogfmtValue(buffer, []byte(f.jsonRaw))
+}
+
+func (f BinaryContent) EncodeLogfmt(buffer Buffer) error {
+	return encodeBinaryDataURI(buffer, f.binaryRaw)
+}
+
+func (f BoolContent) EncodeLogfmt(buffer Buffer) error { return f.EncodeJSON(buffer) }
+
+func (f Complex128Content) EncodeLogfmt(buffer Buffer) error {
+	return errWithoutVal(buffer.WriteString(strconv.FormatComplex(complex128(f), 'f', -1, 128)))
+}
+
+func (f Complex64Content) EncodeLogfmt(buffer Buffer) error {
+	return errWithoutVal(buffer.WriteString(strconv.FormatComplex(complex128(f), 'f', -1, 64)))
+}
+
+func (f ErrorContent) EncodeLogfmt(buffer Buffer) error {
+	if f.data == nil {
+		return NilContent{}.EncodeLogfmt(buffer)
+	}
+	if text, panicked := SafeError(f.data); panicked != nil {
+		return encodeLogfmtContent(buffer, panicked)
+	} else {
+		return writeLogfmtValue(buffer, text)
+	}
+}
+
+func (f Float32Content) EncodeLogfmt(buffer Buffer) error {
+	return errWithoutVal(buffer.WriteString(strconv.FormatFloat(float64(f), 'f', -1, 32)))
+}
+
+func (f Float64Content) EncodeLogfmt(buffer Buffer) error {
+	return errWithoutVal(buffer.WriteString(strconv.FormatFloat(float64(f), 'f', -1, 64)))
+}
+
+func (f IntContent[T]) EncodeLogfmt(buffer Buffer) error { return f.EncodeJSON(buffer) }
+
+func (f UintContent[T]) EncodeLogfmt(buffer Buffer) error { return f.EncodeJSON(buffer) }
+
+func (f UintptrContent) EncodeLogfmt(buffer Buffer) error { return f.EncodeJSON(buffer) }
+
+func (f StringContent) EncodeLogfmt(buffer Buffer) error { return writeLogfmtValue(buffer, string(f)) }
+
+func (f StringerContent) EncodeLogfmt(buffer Buffer) error {
+	if f.data == nil {
+		return NilContent{}.EncodeLogfmt(buffer)
+	}
+	if text, panicked := SafeString(f.data); panicked != nil {
+		return encodeLogfmtContent(buffer, panicked)
+	} else {
+		return writeLogfmtValue(buffer, text)
+	}
+}
+
+func (f TimeFormatContent) EncodeLogfmt(buffer Buffer) error {
+	if f.format != TimeText {
+		return errWithoutVal(buffer.Write(f.AppendJSON(nil)))
+	}
+	var layout = f.layout
+	if layout == "" {
+		layout = time.RFC3339
+	}
+	return writeLogfmtValue(buffer, f.data.Format(layout))
+}
+
+func (f TimeContent) EncodeLogfmt(buffer Buffer) error {
+	return writeLogfmtValue(buffer, time.Time(f).Format(time.RFC3339))
+}
@@ -0,0 +1,47 @@
+package field
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestLogfmtFields(t *testing.T) {
+	var timeVal = time.Date(2023, 5, 20, 23, 15, 16, 0, time.UTC)
+	var list = Fields{
+		String("user", "alice"),
+		String("msg", `say "hi" = bye`),
+		String("empty", ""),
+		Int("count", 3),
+		Float64("ratio", 0.5),
+		Bool("ok", true),
+		Nil("none"),
+		Error("err", errors.New("boom")),
+		Duration("latency", 12*time.Millisecond),
+		Time("at", timeVal),
+		Binary("body", []byte{0x12, 0x34}),
+		Complex128("z", 1-2i),
+		Strings("ids", []string{"a", "b c"}),
+		Ints("nums", []int{1, 2}),
+		Object("http", Object("request", String("method", "GET"), String("path", "/")), Int("status", 200)),
+		Objects("list", []Fields{{Int("a", 1), Int("b", 2)}}),
+		Lazy("lazy", func() Content { return Object("", Int("a", 1)).Content }),
+		JsonRawMessage("raw", []byte(`{"a":1}`)),
+		String("bad key", "x"),
+		{Key: "fn", Content: funcContent{fn: func() {}}},
+	}
+	var buf bytes.Buffer
+	if err := list.EncodeLogfmt(&buf); err != nil {
+		t.Error(fmt.Errorf("cant encode logfmt: %w", err))
+		return
+	}
+	var expected = `at=2023-05-20T23:15:16Z bad_key=x body=data:;base64,EjQ count=3 empty="" err=boom fn="\"func()\"" ` +
+		`http.request.method=GET http.request.path=/ http.status=200 ids="[a,\"b c\"]" latency=12ms lazy.a=1 list="[{a=1 b=2}]" ` +
+		`msg="say \"hi\" = bye" none=null nums=[1,2] ok=true ratio=0.5 raw="{\"a\":1}" user=alice z=(1-2i)`
+	if result := buf.String(); result != expected {
+		t.Errorf("invalid logfmt result: `%s`, expected: `%s`", result, expected)
+		return
+	}
+}
@@ -0,0 +1,579 @@
+package field
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+const (
+	msgpackNil      = 0xc0
+	msgpackFalse    = 0xc2
+	msgpackTrue     = 0xc3
+	msgpackBin8     = 0xc4
+	msgpackBin16    = 0xc5
+	msgpackBin32    = 0xc6
+	msgpackExt8     = 0xc7
+	msgpackExt16    = 0xc8
+	msgpackExt32    = 0xc9
+	msgpackFloat32  = 0xca
+	msgpackFloat64  = 0xcb
+	msgpackUint8    = 0xcc
+	msgpackUint16   = 0xcd
+	msgpackUint32   = 0xce
+	msgpackUint64   = 0xcf
+	msgpackInt8     = 0xd0
+	msgpackInt16    = 0xd1
+	msgpackInt32    = 0xd2
+	msgpackInt64    = 0xd3
+	msgpackFixExt1  = 0xd4
+	msgpackFixExt2  = 0xd5
+	msgpackFixExt4  = 0xd6
+	msgpackFixExt8  = 0xd7
+	msgpackFixExt16 = 0xd8
+	msgpackStr8     = 0xd9
+	msgpackStr16    = 0xda
+	msgpackStr32    = 0xdb
+	msgpackArray16  = 0xdc
+	msgpackArray32  = 0xdd
+	msgpackMap16    = 0xde
+	msgpackMap32    = 0xdf
+
+	msgpackFixMap   = 0x80
+	msgpackFixArray = 0x90
+	msgpackFixStr   = 0xa0
+
+	msgpackExtTimestamp = -1
+)
+
+// EncodeMsgpack encodes fields as a MessagePack map. Binary is kept as bin,
+// time as the timestamp extension, complex numbers become an array of their
+// real and imaginary parts, errors and stringers become str.
+func (f Fields) EncodeMsgpack(buf Buffer) error {
+	return msgpackWriter{buf: buf, budget: loadSizeLimits().MaxTotal}.writeFields(f)
+}
+
+func (f Fields) MarshalMsgpack() (dst []byte, err error) {
+	var buf bytes.Buffer
+	err = f.EncodeMsgpack(&buf)
+	return buf.Bytes(), err
+}
+
+// EncodeMsgpack encodes field as a map holding only itself.
+func (f Field) EncodeMsgpack(buf Buffer) (err error) {
+	f = f.snapshot()
+	var w = msgpackWriter{buf: buf, budget: loadSizeLimits().MaxTotal}
+	if err = w.writeHead(msgpackFixMap, msgpackMap16, msgpackMap32, 1); err != nil {
+		return err
+	}
+	if err = w.writeStr(f.Key); err != nil {
+		return err
+	}
+	return w.writeContent(f.Content)
+}
+
+func (f Field) MarshalMsgpack() (dst []byte, err error) {
+	var buf bytes.Buffer
+	err = f.EncodeMsgpack(&buf)
+	return buf.Bytes(), err
+}
+
+type msgpackWriter struct {
+	buf Buffer
+	// budget is the count of bytes a map may take, zero if unlimited.
+	budget int
+}
+
+func (w msgpackWriter) writeByteAndUint(prefix byte, n uint64, size int) error {
+	var head [9]byte
+	head[0] = prefix
+	switch size {
+	case 1:
+		head[1] = byte(n)
+	case 2:
+		binary.BigEndian.PutUint16(head[1:], uint16(n))
+	case 4:
+		binary.BigEndian.PutUint32(head[1:], uint32(n))
+	case 8:
+		binary.BigEndian.PutUint64(head[1:], n)
+	}
+	return errWithoutVal(w.buf.Write(head[:1+size]))
+}
+
+// writeHead writes header of str, array or map, fix is used for up to 31
+// bytes of str or 15 members of others.
+func (w msgpackWriter) writeHead(fix, prefix16, prefix32 byte, n int) error {
+	var fixMax = 15
+	if fix == msgpackFixStr {
+		fixMax = 31
+	}
+	switch {
+	case n <= fixMax:
+		return w.buf.WriteByte(fix | byte(n))
+	case n <= math.MaxUint16:
+		return w.writeByteAndUint(prefix16, uint64(n), 2)
+	default:
+		return w.writeByteAndUint(prefix32, uint64(n), 4)
+	}
+}
+
+func (w msgpackWriter) writeUint(n uint64) error {
+	switch {
+	case n <= math.MaxInt8:
+		return w.buf.WriteByte(byte(n))
+	case n <= math.MaxUint8:
+		return w.writeByteAndUint(msgpackUint8, n, 1)
+	case n <= math.MaxUint16:
+		return w.writeByteAndUint(msgpackUint16, n, 2)
+	case n <= math.MaxUint32:
+		return w.writeByteAndUint(msgpackUint32, n, 4)
+	default:
+		return w.writeByteAndUint(msgpackUint64, n, 8)
+	}
+}
+
+func (w msgpackWriter) writeInt(n int64) error {
+	switch {
+	case n >= 0:
+		return w.writeUint(uint64(n))
+	case n >= -32:
+		return w.buf.WriteByte(byte(n))
+	case n >= math.MinInt8:
+		return w.writeByteAndUint(msgpackInt8, uint64(n), 1)
+	case n >= math.MinInt16:
+		return w.writeByteAndUint(msgpackInt16, uint64(n), 2)
+	case n >= math.MinInt32:
+		return w.writeByteAndUint(msgpackInt32, uint64(n), 4)
+	default:
+		return w.writeByteAndUint(msgpackInt64, uint64(n), 8)
+	}
+}
+
+func (w msgpackWriter) writeStr(s string) (err error) {
+	if len(s) > 31 && len(s) <= math.MaxUint8 {
+		err = w.writeByteAndUint(msgpackStr8, uint64(len(s)), 1)
+	} else {
+		err = w.writeHead(msgpackFixStr, msgpackStr16, msgpackStr32, len(s))
+	}
+	if err != nil {
+		return err
+	}
+	return errWithoutVal(w.buf.WriteString(s))
+}
+
+func (w msgpackWriter) writeBin(data []byte) (err error) {
+	switch {
+	case len(data) <= math.MaxUint8:
+		err = w.writeByteAndUint(msgpackBin8, uint64(len(data)), 1)
+	case len(data) <= math.MaxUint16:
+		err = w.writeByteAndUint(msgpackBin16, uint64(len(data)), 2)
+	default:
+		err = w.writeByteAndUint(msgpackBin32, uint64(len(data)), 4)
+	}
+	if err != nil {
+		return err
+	}
+	return errWithoutVal(w.buf.Write(data))
+}
+
+// writeTime picks the smallest of timestamp 32, 64 and 96 formats.
+func (w msgpackWriter) writeTime(t time.Time) error {
+	var sec, nsec = t.Unix(), uint64(t.Nanosecond())
+	switch {
+	case sec >= 0 && sec>>32 == 0 && nsec == 0:
+		var data [6]byte
+		data[0], data[1] = msgpackFixExt4, byte(msgpackExtTimestamp&0xff)
+		binary.BigEndian.PutUint32(data[2:], uint32(sec))
+		return errWithoutVal(w.buf.Write(data[:]))
+	case sec >= 0 && sec>>34 == 0:
+		var data [10]byte
+		data[0], data[1] = msgpackFixExt8, byte(msgpackExtTimestamp&0xff)
+		binary.BigEndian.PutUint64(data[2:], nsec<<34|uint64(sec))
+		return errWithoutVal(w.buf.Write(data[:]))
+	default:
+		var data [15]byte
+		data[0], data[1], data[2] = msgpackExt8, 12, byte(msgpackExtTimestamp&0xff)
+		binary.BigEndian.PutUint32(data[3:], uint32(nsec))
+		binary.BigEndian.PutUint64(data[7:], uint64(sec))
+		return errWithoutVal(w.buf.Write(data[:]))
+	}
+}
+
+func (w msgpackWriter) writeFields(fields Fields) (err error) {
+	var snap = fields.Snapshot()
+	if w.budget > 0 {
+		return w.writeFieldsLimited(snap)
+	}
+	if err = w.writeHead(msgpackFixMap, msgpackMap16, msgpackMap32, len(snap)); err != nil {
+		return err
+	}
+	for i := 0; i < len(snap); i++ {
+		if err = w.writeStr(snap[i].Key); err != nil {
+			return err
+		}
+		if err = w.writeContent(snap[i].Content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeFieldsLimited writes snap as a map of at most w.budget bytes, fields
+// over it are dropped for a "!TRUNCATED" count.
+func (w msgpackWriter) writeFieldsLimited(snap []Field) (err error) {
+	var head sliceBuffer
+	_ = msgpackWriter{buf: &head}.writeHead(msgpackFixMap, msgpackMap16, msgpackMap32, len(snap)+1)
+	var body, count, limitErr = appendLimited(nil, snap, w.budget-len(head.data), entryEncoder{
+		appendField: func(dst []byte, f Field, budget int) ([]byte, error) {
+			var buf = sliceBuffer{data: dst}
+			var fw = msgpackWriter{buf: &buf}
+			if err := fw.writeStr(f.Key); err != nil {
+				return buf.data, err
+			}
+			fw.budget = nestedBudget(budget, len(buf.data)-len(dst))
+			var err = fw.writeContent(f.Content)
+			return buf.data, err
+		},
+		appendMarker: func(dst []byte, dropped int) []byte {
+			var buf = sliceBuffer{data: dst}
+			var fw = msgpackWriter{buf: &buf}
+			_ = fw.writeStr(truncatedKey)
+			_ = fw.writeInt(int64(dropped))
+			return buf.data
+		},
+	})
+	if limitErr != nil {
+		return limitErr
+	}
+	if err = w.writeHead(msgpackFixMap, msgpackMap16, msgpackMap32, count); err != nil {
+		return err
+	}
+	return errWithoutVal(w.buf.Write(body))
+}
+
+func (w msgpackWriter) writeContent(content Content) (err error) {
+	switch v := content.(type) {
+	case nil, NilContent:
+		return w.buf.WriteByte(msgpackNil)
+	case BoolContent:
+		if v {
+			return w.buf.WriteByte(msgpackTrue)
+		}
+		return w.buf.WriteByte(msgpackFalse)
+	case ArrayContent:
+		if err = w.writeHead(msgpackFixArray, msgpackArray16, msgpackArray32, len(v.arrayRaw)); err != nil {
+			return err
+		}
+		for i := 0; i < len(v.arrayRaw); i++ {
+			if err = w.writeContent(v.arrayRaw[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	case ObjectContent:
+		return w.writeFields(v.fields)
+	case BinaryContent:
+		return w.writeBin(v.binaryRaw)
+	case StringContent:
+		return w.writeStr(string(v))
+	case Float32Content:
+		return w.writeByteAndUint(msgpackFloat32, uint64(math.Float32bits(float32(v))), 4)
+	case Float64Content:
+		return w.writeByteAndUint(msgpackFloat64, math.Float64bits(float64(v)), 8)
+	case Complex64Content:
+		return w.writeContent(ArrayContent{arrayRaw: []Content{Float32Content(real(v)), Float32Content(imag(v))}})
+	case Complex128Content:
+		return w.writeContent(ArrayContent{arrayRaw: []Content{Float64Content(real(v)), Float64Content(imag(v))}})
+	case TimeContent:
+		return w.writeTime(time.Time(v))
+	case TimeFormatContent:
+		return w.writeTime(v.data)
+	case LazyContent:
+		return w.writeContent(v.Raw())
+	case StackContent:
+		return w.writeContent(v.content())
+	case CallerContent:
+		return w.writeContent(v.content())
+	case UintptrContent:
+		return w.writeUint(uint64(v))
+	case ErrorContent:
+		if v.data == nil {
+			return w.buf.WriteByte(msgpackNil)
+		}
+		if text, panicked := SafeError(v.data); panicked != nil {
+			return w.writeContent(panicked)
+		} else {
+			return w.writeStr(text)
+		}
+	case StringerContent:
+		if v.data == nil {
+			return w.buf.WriteByte(msgpackNil)
+		}
+		if text, panicked := SafeString(v.data); panicked != nil {
+			return w.writeContent(panicked)
+		} else {
+			return w.writeStr(text)
+		}
+	case JSONContent:
+		if decoded, decodeErr := defaultDecoderConfig.DecodeContent(v.jsonRaw); decodeErr == nil {
+			return w.writeContent(decoded)
+		}
+		return w.writeStr(string(v.jsonRaw))
+	}
+	switch content.Type() {
+	case TypeString:
+		if s, ok := content.Data().(string); ok {
+			return w.writeStr(s)
+		}
+	case TypeInt:
+		if i, ok := IntData(content); ok {
+			return w.writeInt(i)
+		}
+	case TypeUint:
+		if u, ok := UintData(content); ok {
+			return w.writeUint(u)
+		}
+	}
+	var jsonBuf bytes.Buffer
+	if err = content.EncodeJSON(&jsonBuf); err != nil {
+		return err
+	}
+	return w.writeStr(jsonBuf.String())
+}
+
+// decoder
+
+var errMsgpackTruncated = errors.New("cant unmarshal msgpack: unexpected end of data")
+
+func (f *Fields) UnmarshalMsgpack(data []byte) error {
+	var fields, err = DecodeMsgpack(data)
+	if err != nil {
+		return err
+	}
+	*f = fields
+	return nil
+}
+
+// UnmarshalMsgpack is the reverse of Field.MarshalMsgpack, which expects a
+// map holding exactly one member.
+func (f *Field) UnmarshalMsgpack(data []byte) error {
+	var fields, err = DecodeMsgpack(data)
+	if err != nil {
+		return err
+	}
+	if len(fields) != 1 {
+		return fmt.Errorf("cant unmarshal field: expect 1 member, got %d", len(fields))
+	}
+	*f = fields[0]
+	return nil
+}
+
+// DecodeMsgpack decodes a MessagePack map to Fields. Integers take the best
+// fitting of int64 and uint64 content, timestamps are recovered as
+// TimeContent and other extension types as BinaryContent of their data.
+func DecodeMsgpack(data []byte) (Fields, error) {
+	var r = msgpackReader{data: data}
+	var content, err = r.readContent(0)
+	if err != nil {
+		return nil, err
+	}
+	if r.pos != len(r.data) {
+		return nil, fmt.Errorf("cant unmarshal msgpack: unexpected data after top-level value")
+	}
+	if object, ok := content.(ObjectContent); ok {
+		return object.fields, nil
+	}
+	return nil, fmt.Errorf("cant unmarshal msgpack: not a map")
+}
+
+type msgpackReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *msgpackReader) readN(n uint64) ([]byte, error) {
+	if n > uint64(len(r.data)-r.pos) {
+		return nil, errMsgpackTruncated
+	}
+	r.pos += int(n)
+	return r.data[r.pos-int(n) : r.pos], nil
+}
+
+func (r *msgpackReader) readUint(size int) (uint64, error) {
+	var raw, err = r.readN(uint64(size))
+	if err != nil {
+		return 0, err
+	}
+	var n uint64
+	for i := 0; i < len(raw); i++ {
+		n = n<<8 | uint64(raw[i])
+	}
+	return n, nil
+}
+
+func (r *msgpackReader) readContent(depth int) (Content, error) {
+	if depth > maxReflectDepth {
+		return nil, fmt.Errorf("cant unmarshal msgpack: exceeded max depth %d", maxReflectDepth)
+	}
+	var raw, err = r.readN(1)
+	if err != nil {
+		return nil, err
+	}
+	var prefix = raw[0]
+	switch {
+	case prefix <= 0x7f:
+		return NewIntContent(int64(prefix)), nil
+	case prefix >= 0xe0:
+		return NewIntContent(int64(int8(prefix))), nil
+	case prefix&0xf0 == msgpackFixMap:
+		return r.readMap(uint64(prefix&0x0f), depth)
+	case prefix&0xf0 == msgpackFixArray:
+		return r.readArray(uint64(prefix&0x0f), depth)
+	case prefix&0xe0 == msgpackFixStr:
+		return r.readStr(uint64(prefix & 0x1f))
+	}
+	switch prefix {
+	case msgpackNil:
+		return NilContent{}, nil
+	case msgpackFalse:
+		return BoolContent(false), nil
+	case msgpackTrue:
+		return BoolContent(true), nil
+	case msgpackBin8, msgpackBin16, msgpackBin32:
+		var n uint64
+		if n, err = r.readUint(1 << (prefix - msgpackBin8)); err != nil {
+			return nil, err
+		}
+		if raw, err = r.readN(n); err != nil {
+			return nil, err
+		}
+		return BinaryContent{binaryRaw: append([]byte(nil), raw...)}, nil
+	case msgpackExt8, msgpackExt16, msgpackExt32:
+		var n uint64
+		if n, err = r.readUint(1 << (prefix - msgpackExt8)); err != nil {
+			return nil, err
+		}
+		return r.readExt(n)
+	case msgpackFixExt1, msgpackFixExt2, msgpackFixExt4, msgpackFixExt8, msgpackFixExt16:
+		return r.readExt(1 << (prefix - msgpackFixExt1))
+	case msgpackFloat32:
+		var n uint64
+		if n, err = r.readUint(4); err != nil {
+			return nil, err
+		}
+		return Float32Content(math.Float32frombits(uint32(n))), nil
+	case msgpackFloat64:
+		var n uint64
+		if n, err = r.readUint(8); err != nil {
+			return nil, err
+		}
+		return Float64Content(math.Float64frombits(n)), nil
+	case msgpackUint8, msgpackUint16, msgpackUint32, msgpackUint64:
+		var n uint64
+		if n, err = r.readUint(1 << (prefix - msgpackUint8)); err != nil {
+			return nil, err
+		}
+		if n <= math.MaxInt64 {
+			return NewIntContent(int64(n)), nil
+		}
+		return NewUintContent(n), nil
+	case msgpackInt8, msgpackInt16, msgpackInt32, msgpackInt64:
+		var size = 1 << (prefix - msgpackInt8)
+		var n uint64
+		if n, err = r.readUint(size); err != nil {
+			return nil, err
+		}
+		// sign extension from the highest bit of size bytes
+		var shift = uint(64 - 8*size)
+		return NewIntContent(int64(n<<shift) >> shift), nil
+	case msgpackStr8, msgpackStr16, msgpackStr32:
+		var n uint64
+		if n, err = r.readUint(1 << (prefix - msgpackStr8)); err != nil {
+			return nil, err
+		}
+		return r.readStr(n)
+	case msgpackArray16, msgpackArray32:
+		var n uint64
+		if n, err = r.readUint(2 << (prefix - msgpackArray16)); err != nil {
+			return nil, err
+		}
+		return r.readArray(n, depth)
+	case msgpackMap16, msgpackMap32:
+		var n uint64
+		if n, err = r.readUint(2 << (prefix - msgpackMap16)); err != nil {
+			return nil, err
+		}
+		return r.readMap(n, depth)
+	}
+	return nil, fmt.Errorf("cant unmarshal msgpack: invalid prefix 0x%02x", prefix)
+}
+
+func (r *msgpackReader) readStr(n uint64) (Content, error) {
+	var raw, err = r.readN(n)
+	if err != nil {
+		return nil, err
+	}
+	return StringContent(raw), nil
+}
+
+// capHint limits preallocation by the remaining data, as each item takes
+// at least one byte.
+func (r *msgpackReader) capHint(n uint64) int {
+	if remain := uint64(len(r.data) - r.pos); n > remain {
+		return int(remain)
+	}
+	return int(n)
+}
+
+func (r *msgpackReader) readArray(n uint64, depth int) (Content, error) {
+	var list = make([]Content, 0, r.capHint(n))
+	for i := uint64(0); i < n; i++ {
+		var elem, err = r.readContent(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, elem)
+	}
+	return ArrayContent{arrayRaw: list}, nil
+}
+
+func (r *msgpackReader) readMap(n uint64, depth int) (Content, error) {
+	var fields = make(Fields, 0, r.capHint(n))
+	for i := uint64(0); i < n; i++ {
+		var key, err = r.readContent(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		var val Content
+		if val, err = r.readContent(depth + 1); err != nil {
+			return nil, err
+		}
+		fields = append(fields, Field{Key: contentKeyString(key), Content: val})
+	}
+	return ObjectContent{fields: fields}, nil
+}
+
+func (r *msgpackReader) readExt(n uint64) (Content, error) {
+	var raw, err = r.readN(n + 1)
+	if err != nil {
+		return nil, err
+	}
+	var extType, data = int8(raw[0]), raw[1:]
+	if extType != msgpackExtTimestamp {
+		return BinaryContent{binaryRaw: append([]byte(nil), data...)}, nil
+	}
+	switch len(data) {
+	case 4:
+		return TimeContent(time.Unix(int64(binary.BigEndian.Uint32(data)), 0)), nil
+	case 8:
+		var n = binary.BigEndian.Uint64(data)
+		return TimeContent(time.Unix(int64(n&(1<<34-1)), int64(n>>34))), nil
+	case 12:
+		var nsec, sec = binary.BigEndian.Uint32(data), binary.BigEndian.Uint64(data[4:])
+		return TimeContent(time.Unix(int64(sec), int64(nsec))), nil
+	}
+	return nil, fmt.Errorf("cant unmarshal msgpack: invalid timestamp of %d bytes", len(data))
+}
@@ -0,0 +1,126 @@
+package field
+
+import (
+	"bytes"
+	stdhex "encoding/hex"
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMsgpackEncoder(t *testing.T) {
+	var tests = []struct {
+		name    string
+		content Content
+		expect  string
+	}{
+		{"nil", NilContent{}, "c0"},
+		{"bool", BoolContent(false), "c2"},
+		{"fixint", NewIntContent(127), "7f"},
+		{"negFixint", NewIntContent(-32), "e0"},
+		{"uint8", NewUintContent[uint8](200), "ccc8"},
+		{"int8", NewIntContent[int8](-33), "d0df"},
+		{"int16", NewIntContent[int64](-1000), "d1fc18"},
+		{"uint32", NewUintContent[uint32](70000), "ce00011170"},
+		{"int64", NewIntContent[int64](math.MinInt64), "d38000000000000000"},
+		{"uint64", NewUintContent[uint64](math.MaxUint64), "cfffffffffffffffff"},
+		{"float32", Float32Content(1.5), "ca3fc00000"},
+		{"float64", Float64Content(1.5), "cb3ff8000000000000"},
+		{"complex128", Complex128Content(1 + 0i), "92cb3ff0000000000000cb0000000000000000"},
+		{"fixstr", StringContent("abc"), "a3616263"},
+		{"str8", StringContent(strings.Repeat("a", 32)), "d920" + strings.Repeat("61", 32)},
+		{"bin", NewBinaryContent([]byte{1, 2}), "c4020102"},
+		{"time32", TimeContent(time.Unix(1, 0)), "d6ff00000001"},
+		{"time64", TimeContent(time.Unix(1, 1)), "d7ff0000000400000001"},
+		{"time96", TimeContent(time.Unix(-1, 1)), "c70cff00000001ffffffffffffffff"},
+		{"error", NewErrorContent(errors.New("boom")), "a4626f6f6d"},
+		{"json", NewJSONContent([]byte(`[true]`)), "91c3"},
+		{"object", NewObjectContent(Int("b", 1), Int("a", 2)), "82a16102a16201"},
+	}
+	for _, testItem := range tests {
+		t.Run(testItem.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := (msgpackWriter{buf: &buf}).writeContent(testItem.content); err != nil {
+				t.Error(err)
+				return
+			}
+			if result := stdhex.EncodeToString(buf.Bytes()); result != testItem.expect {
+				t.Errorf("invalid msgpack result: %s, expected: %s", result, testItem.expect)
+			}
+		})
+	}
+}
+
+func TestMsgpackDecode(t *testing.T) {
+	var source = Fields{
+		Nil("null"),
+		Bool("bool", true),
+		Int64("int", math.MinInt64),
+		Int16("int16", -300),
+		Uint64("uint", math.MaxUint64),
+		Float32("float32", 1.5),
+		Float64("float64", -2.25),
+		String("string", strings.Repeat("s", 300)),
+		Binary("binary", []byte{0x12, 0x34}),
+		Time("time", time.Unix(-100, 5)),
+		Ints("ints", make([]int, 20)),
+		Object("object", String("b", "x")),
+	}
+	var data, err = source.MarshalMsgpack()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	var fields Fields
+	if err = fields.UnmarshalMsgpack(data); err != nil {
+		t.Error(err)
+		return
+	}
+	var expects = map[string]Content{
+		"null":    NilContent{},
+		"bool":    BoolContent(true),
+		"int":     NewIntContent[int64](math.MinInt64),
+		"int16":   NewIntContent[int64](-300),
+		"uint":    NewUintContent[uint64](math.MaxUint64),
+		"float32": Float32Content(1.5),
+		"float64": Float64Content(-2.25),
+		"string":  StringContent(strings.Repeat("s", 300)),
+		"binary":  BinaryContent{binaryRaw: []byte{0x12, 0x34}},
+		"time":    TimeContent(time.Unix(-100, 5)),
+		"ints":    ArrayContent{arrayRaw: []Content{}},
+		"object":  ObjectContent{fields: Fields{String("b", "x")}},
+	}
+	for i := 0; i < 20; i++ {
+		expects["ints"] = ArrayContent{arrayRaw: append(expects["ints"].(ArrayContent).arrayRaw, NewIntContent[int64](0))}
+	}
+	if len(fields) != len(source) {
+		t.Errorf("invalid decoded fields: %v", fields)
+	}
+	for _, field := range fields {
+		if !reflect.DeepEqual(field.Content, expects[field.Key]) {
+			t.Errorf("invalid decoded field %q: %#v", field.Key, field.Content)
+		}
+	}
+	t.Run("field", func(t *testing.T) {
+		var data, _ = String("k", "v").MarshalMsgpack()
+		var field Field
+		if err := field.UnmarshalMsgpack(data); err != nil {
+			t.Error(err)
+			return
+		}
+		if field.Key != "k" || field.Content != StringContent("v") {
+			t.Errorf("invalid decoded field: %#v", field)
+		}
+	})
+	t.Run("invalid", func(t *testing.T) {
+		for _, input := range []string{"", "81", "81a161", "91c0", "dd7fffffff", "80c1", "81a161d6ff00"} {
+			var raw, _ = stdhex.DecodeString(input)
+			if _, err := DecodeMsgpack(raw); err == nil {
+				t.Errorf("expect error for %s", input)
+			}
+		}
+	})
+}
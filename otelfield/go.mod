@@ -0,0 +1,18 @@
+module github.com/go-haru/field/otelfield
+
+go 1.18
+
+require (
+	github.com/go-haru/field v0.0.0
+	go.opentelemetry.io/otel v1.14.0
+	go.opentelemetry.io/otel/sdk v1.14.0
+)
+
+require (
+	github.com/go-logr/logr v1.2.3 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	go.opentelemetry.io/otel/trace v1.14.0 // indirect
+	golang.org/x/sys v0.12.0 // indirect
+)
+
+replace github.com/go-haru/field => ../
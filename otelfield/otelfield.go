@@ -0,0 +1,220 @@
+// Package otelfield converts between field.Field and attribute.KeyValue, so
+// the context attached to logs can be attached to spans and the other way.
+//
+// Attributes only hold bool, int64, float64, string and slices of them. Other
+// contents fall back to strings: complex numbers are formatted by strconv,
+// times as RFC3339Nano, binaries in standard base64, errors by their message,
+// stringers by fmt. Objects, nulls, arrays of mixed or unsupported elements
+// and unknown contents are written as their JSON text.
+package otelfield
+
+import (
+	"bytes"
+	"encoding/base64"
+	"math"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/go-haru/field"
+)
+
+// ToAttribute converts f to attribute.KeyValue. The redaction policy and the
+// size limits of package field are applied like field.Fields.Snapshot.
+func ToAttribute(f field.Field) attribute.KeyValue { return toAttribute(field.Fields{f}.Snapshot()[0]) }
+
+func toAttribute(f field.Field) attribute.KeyValue {
+	var key = attribute.Key(f.Key)
+	if lazy, ok := f.Content.(field.LazyContent); ok {
+		f.Content = lazy.Raw()
+	}
+	if array, ok := f.Content.(field.ArrayContent); ok {
+		if kv, ok := sliceAttribute(key, array.Raw()); ok {
+			return kv
+		}
+		return key.String(jsonText(f.Content))
+	}
+	var value, ok = scalarValue(f.Content)
+	if !ok {
+		return key.String(jsonText(f.Content))
+	}
+	switch v := value.(type) {
+	case bool:
+		return key.Bool(v)
+	case int64:
+		return key.Int64(v)
+	case float64:
+		return key.Float64(v)
+	}
+	return key.String(value.(string))
+}
+
+// Attributes converts the snapshot of f to []attribute.KeyValue, it is not
+// a method of field.Fields to keep the field package free of dependencies.
+func Attributes(f field.Fields) []attribute.KeyValue {
+	var snap = f.Snapshot()
+	var result = make([]attribute.KeyValue, len(snap))
+	for i := 0; i < len(snap); i++ {
+		result[i] = toAttribute(snap[i])
+	}
+	return result
+}
+
+// scalarValue converts content to bool, int64, float64 or string, ok is false
+// if content should be written as its JSON text.
+func scalarValue(content field.Content) (_ any, ok bool) {
+	switch v := content.(type) {
+	case field.LazyContent:
+		return scalarValue(v.Raw())
+	case nil, field.NilContent, field.ObjectContent, field.ArrayContent, field.JSONContent:
+		return nil, false
+	case field.BoolContent:
+		return v.Raw(), true
+	case field.Float32Content:
+		return float64(v.Raw()), true
+	case field.Float64Content:
+		return v.Raw(), true
+	case field.Complex64Content:
+		return strconv.FormatComplex(complex128(v.Raw()), 'f', -1, 64), true
+	case field.Complex128Content:
+		return strconv.FormatComplex(v.Raw(), 'f', -1, 128), true
+	case field.StringContent:
+		return v.Raw(), true
+	case field.BinaryContent:
+		return base64.StdEncoding.EncodeToString(v.Raw()), true
+	case field.TimeContent:
+		return v.Raw().Format(time.RFC3339Nano), true
+	case field.TimeFormatContent:
+		// keep the format chosen for the field, text is unquoted
+		var text = jsonText(v)
+		if unquoted, err := strconv.Unquote(text); err == nil {
+			return unquoted, true
+		}
+		return text, true
+	case field.UintptrContent:
+		return uintValue(uint64(v.Raw())), true
+	case field.ErrorContent:
+		if v.Raw() == nil {
+			return nil, false
+		}
+		if text, panicked := field.SafeError(v.Raw()); panicked == nil {
+			return text, true
+		}
+		return nil, false
+	case field.StringerContent:
+		if v.Raw() == nil {
+			return nil, false
+		}
+		if text, panicked := field.SafeString(v.Raw()); panicked == nil {
+			return text, true
+		}
+		return nil, false
+	}
+	if i, ok := field.IntData(content); ok {
+		return i, true
+	}
+	if u, ok := field.UintData(content); ok {
+		return uintValue(u), true
+	}
+	if data, ok := content.Data().(string); ok {
+		return data, true
+	}
+	return nil, false
+}
+
+// uintValue keeps u as int64 if it fits, or its decimal text otherwise.
+func uintValue(u uint64) any {
+	if u > math.MaxInt64 {
+		return strconv.FormatUint(u, 10)
+	}
+	return int64(u)
+}
+
+// sliceAttribute converts list to a slice attribute if all its elements
+// convert to scalars of the same kind.
+func sliceAttribute(key attribute.Key, list []field.Content) (attribute.KeyValue, bool) {
+	if len(list) == 0 {
+		return key.StringSlice([]string{}), true
+	}
+	var values = make([]any, len(list))
+	for i := 0; i < len(list); i++ {
+		var ok bool
+		if values[i], ok = scalarValue(list[i]); !ok {
+			return attribute.KeyValue{}, false
+		}
+	}
+	switch values[0].(type) {
+	case bool:
+		if bools, ok := sameKind[bool](values); ok {
+			return key.BoolSlice(bools), true
+		}
+	case int64:
+		if ints, ok := sameKind[int64](values); ok {
+			return key.Int64Slice(ints), true
+		}
+	case float64:
+		if floats, ok := sameKind[float64](values); ok {
+			return key.Float64Slice(floats), true
+		}
+	case string:
+		if strs, ok := sameKind[string](values); ok {
+			return key.StringSlice(strs), true
+		}
+	}
+	return attribute.KeyValue{}, false
+}
+
+func sameKind[T any](values []any) (_ []T, ok bool) {
+	var result = make([]T, len(values))
+	for i := 0; i < len(values); i++ {
+		if result[i], ok = values[i].(T); !ok {
+			return nil, false
+		}
+	}
+	return result, true
+}
+
+func jsonText(content field.Content) string {
+	if content == nil {
+		content = field.NilContent{}
+	}
+	var buf bytes.Buffer
+	if err := content.EncodeJSON(&buf); err != nil {
+		return err.Error()
+	}
+	return buf.String()
+}
+
+// FromAttribute converts kv to field.Field, invalid attributes become nil.
+func FromAttribute(kv attribute.KeyValue) field.Field {
+	var key = string(kv.Key)
+	switch kv.Value.Type() {
+	case attribute.BOOL:
+		return field.Bool(key, kv.Value.AsBool())
+	case attribute.INT64:
+		return field.Int64(key, kv.Value.AsInt64())
+	case attribute.FLOAT64:
+		return field.Float64(key, kv.Value.AsFloat64())
+	case attribute.STRING:
+		return field.String(key, kv.Value.AsString())
+	case attribute.BOOLSLICE:
+		return field.Bools(key, kv.Value.AsBoolSlice())
+	case attribute.INT64SLICE:
+		return field.Int64s(key, kv.Value.AsInt64Slice())
+	case attribute.FLOAT64SLICE:
+		return field.Float64s(key, kv.Value.AsFloat64Slice())
+	case attribute.STRINGSLICE:
+		return field.Strings(key, kv.Value.AsStringSlice())
+	}
+	return field.Nil(key)
+}
+
+// FromAttributes converts attrs to field.Fields.
+func FromAttributes(attrs []attribute.KeyValue) field.Fields {
+	var result = make(field.Fields, len(attrs))
+	for i := 0; i < len(attrs); i++ {
+		result[i] = FromAttribute(attrs[i])
+	}
+	return result
+}
@@ -0,0 +1,121 @@
+package otelfield
+
+import (
+	"context"
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/go-haru/field"
+)
+
+func TestToAttribute(t *testing.T) {
+	var tests = []struct {
+		name   string
+		field  field.Field
+		expect attribute.KeyValue
+	}{
+		{"Bool", field.Bool("k", true), attribute.Bool("k", true)},
+		{"Int8", field.Int8("k", -1), attribute.Int64("k", -1)},
+		{"Uint64", field.Uint64("k", 2), attribute.Int64("k", 2)},
+		{"Uint64Overflow", field.Uint64("k", math.MaxUint64), attribute.String("k", "18446744073709551615")},
+		{"Uintptr", field.Uintptr("k", 2), attribute.Int64("k", 2)},
+		{"UintptrOverflow", field.Uintptr("k", ^uintptr(0)), attribute.String("k", "18446744073709551615")},
+		{"Float32", field.Float32("k", 1.5), attribute.Float64("k", 1.5)},
+		{"String", field.String("k", "v"), attribute.String("k", "v")},
+		{"Complex", field.Complex128("k", 1+2i), attribute.String("k", "(1+2i)")},
+		{"Time", field.Time("k", time.Unix(1, 5).UTC()), attribute.String("k", "1970-01-01T00:00:01.000000005Z")},
+		{"Binary", field.Binary("k", []byte{1, 2}), attribute.String("k", "AQI=")},
+		{"Error", field.Error("k", errors.New("boom")), attribute.String("k", "boom")},
+		{"Duration", field.Duration("k", time.Second), attribute.String("k", "1s")},
+		{"Nil", field.Nil("k"), attribute.String("k", "null")},
+		{"Bools", field.Bools("k", []bool{true}), attribute.BoolSlice("k", []bool{true})},
+		{"Ints", field.Ints("k", []int{1, 2}), attribute.Int64Slice("k", []int64{1, 2})},
+		{"Float64s", field.Float64s("k", []float64{1.5}), attribute.Float64Slice("k", []float64{1.5})},
+		{"Strings", field.Strings("k", []string{"a"}), attribute.StringSlice("k", []string{"a"})},
+		{"Errors", field.Errors("k", []error{errors.New("a")}), attribute.StringSlice("k", []string{"a"})},
+		{"Empty", field.Strings("k", nil), attribute.StringSlice("k", []string{})},
+		{"Mixed", field.Any("k", []any{1, "a"}), attribute.String("k", `[1,"a"]`)},
+		{"Object", field.Object("k", field.Int("a", 1)), attribute.String("k", `{"a":1}`)},
+		{"Lazy", field.LazyAny("k", func() any { return 1 }), attribute.Int64("k", 1)},
+		{"LazyInts", field.LazyAny("k", func() any { return []int{1} }), attribute.Int64Slice("k", []int64{1})},
+	}
+	for _, testItem := range tests {
+		t.Run(testItem.name, func(t *testing.T) {
+			var result = ToAttribute(testItem.field)
+			if result.Key != testItem.expect.Key || !reflect.DeepEqual(result.Value.AsInterface(), testItem.expect.Value.AsInterface()) {
+				t.Errorf("invalid attribute: %v, expected: %v", result.Value.Emit(), testItem.expect.Value.Emit())
+				return
+			}
+		})
+	}
+}
+
+func TestSpanAttributes(t *testing.T) {
+	var exporter = tracetest.NewInMemoryExporter()
+	var provider = sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	var fields = field.Fields{
+		field.String("user", "alice"),
+		field.String("user", "bob"),
+		field.Int("count", 3),
+		field.Float64s("ratio", []float64{0.5}),
+		field.Bools("flags", []bool{true, false}),
+	}
+	var _, span = provider.Tracer("test").Start(context.Background(), "op")
+	span.SetAttributes(Attributes(fields)...)
+	span.End()
+	var spans = exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Errorf("invalid span count: %d", len(spans))
+		return
+	}
+	var result, _ = FromAttributes(spans[0].Attributes).MarshalJSON()
+	var expected = `{"count":3,"flags":[true,false],"ratio":[0.5],"user":"alice"}`
+	if string(result) != expected {
+		t.Errorf("invalid fields: %s, expected: %s", result, expected)
+		return
+	}
+}
+
+func TestRedaction(t *testing.T) {
+	field.SetRedactionPolicy(field.RedactionPolicy{{Keys: []string{"password"}}})
+	defer field.SetRedactionPolicy(nil)
+	var list = field.Fields{field.String("password", "hunter2"), field.Object("user", field.String("password", "hunter2"))}
+	var expected = []attribute.KeyValue{attribute.String("password", "[REDACTED]"), attribute.String("user", `{"password":"[REDACTED]"}`)}
+	if result := Attributes(list); !reflect.DeepEqual(result, expected) {
+		t.Errorf("invalid attributes: %v, expected: %v", result, expected)
+		return
+	}
+	if result := ToAttribute(list[0]); result != expected[0] {
+		t.Errorf("invalid attribute: %v, expected: %v", result, expected[0])
+	}
+}
+
+type testPanicValue struct{ msg string }
+
+func (v *testPanicValue) Error() string { return v.msg }
+
+func (v *testPanicValue) String() string { return v.msg }
+
+func TestPanicRecovery(t *testing.T) {
+	var nilValue *testPanicValue
+	var list = []field.Field{
+		field.Error("k", nilValue),
+		field.Stringer("k", nilValue),
+		field.Errors("k", []error{nilValue}),
+	}
+	for _, item := range list {
+		var result = ToAttribute(item)
+		if !strings.Contains(result.Value.Emit(), `"!PANIC"`) {
+			t.Errorf("invalid attribute: %v", result.Value.Emit())
+			return
+		}
+	}
+}
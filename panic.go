@@ -0,0 +1,65 @@
+package field
+
+import "fmt"
+
+// panicKey is the member of placeholders holding the value recovered from a
+// panicking user method, like {"!PANIC":"boom","type":"*pkg.T"}.
+const panicKey = "!PANIC"
+
+func panicContent(val any, recovered any) Content {
+	return ObjectContent{fields: Fields{
+		String(panicKey, fmt.Sprint(recovered)),
+		String("type", fmt.Sprintf("%T", val)),
+	}}
+}
+
+// recoverField replaces *f with a placeholder if constructing it from val
+// panicked, it must be deferred.
+func recoverField(f *Field, key string, val any) {
+	if recovered := recover(); recovered != nil {
+		*f = Field{Key: key, Content: panicContent(val, recovered)}
+	}
+}
+
+// SafeString calls String of s with panics recovered, panicked holds a
+// placeholder like {"!PANIC":"boom","type":"*pkg.T"} if it panicked.
+func SafeString(s fmt.Stringer) (text string, panicked Content) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			panicked = panicContent(s, recovered)
+		}
+	}()
+	return s.String(), nil
+}
+
+// SafeError calls Error of err with panics recovered, panicked holds the
+// placeholder like SafeString.
+func SafeError(err error) (text string, panicked Content) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			panicked = panicContent(err, recovered)
+		}
+	}()
+	return err.Error(), nil
+}
+
+// appendErrorJSON appends err written by the first json.Marshaler in its
+// chain or as its message, the placeholder is appended if either panicked.
+func appendErrorJSON(dst []byte, err error, escapeHTML bool) (result []byte, marshalErr error) {
+	if err == nil {
+		return append(dst, "null"...), nil
+	}
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			result, marshalErr = panicContent(err, recovered).(ObjectContent).AppendJSON(dst), nil
+		}
+	}()
+	if marshaler, ok := asJSONMarshaler(err); ok {
+		var data []byte
+		if data, marshalErr = marshaler.MarshalJSON(); marshalErr != nil {
+			return dst, marshalErr
+		}
+		return append(dst, data...), nil
+	}
+	return appendString(dst, err.Error(), escapeHTML), nil
+}
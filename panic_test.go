@@ -0,0 +1,81 @@
+package field
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+type testPanicError struct{ msg string }
+
+func (e *testPanicError) Error() string { return e.msg }
+
+type testPanicJSONError struct{ code *int }
+
+func (e testPanicJSONError) Error() string { return "json" }
+
+func (e testPanicJSONError) MarshalJSON() ([]byte, error) { return []byte(fmt.Sprint(*e.code)), nil }
+
+type testPanicMarshaler struct{}
+
+func (m testPanicMarshaler) MarshalJSON() ([]byte, error) { panic("boom") }
+
+type testPanicKey struct{}
+
+func (k testPanicKey) MarshalText() ([]byte, error) { panic("boom") }
+
+const testNilPanic = "runtime error: invalid memory address or nil pointer dereference"
+
+func TestPanicContent(t *testing.T) {
+	var tests = []struct {
+		name     string
+		field    Field
+		expect   string
+		jsonOnly bool
+	}{
+		{"nilStringer", Stringer("k", (*testStringer)(nil)), `{"k":{"!PANIC":"` + testNilPanic + `","type":"*field.testStringer"}}`, false},
+		{"panicStringer", Stringer("k", testPanicStringer{}), `{"k":{"!PANIC":"boom","type":"field.testPanicStringer"}}`, false},
+		{"nilError", Error("k", (*testPanicError)(nil)), `{"k":{"!PANIC":"` + testNilPanic + `","type":"*field.testPanicError"}}`, false},
+		{"wrappedNilError", Error("k", fmt.Errorf("wrap: %w", testPanicJSONError{})), `{"k":{"!PANIC":"` + testNilPanic + `","type":"*fmt.wrapError"}}`, true},
+		{"errors", Errors("k", []error{(*testPanicError)(nil)}), `{"k":[{"!PANIC":"` + testNilPanic + `","type":"*field.testPanicError"}]}`, false},
+		{"anyMarshaler", Any("k", testPanicMarshaler{}), `{"k":{"!PANIC":"boom","type":"field.testPanicMarshaler"}}`, false},
+		{"mapKey", Map("k", map[testPanicKey]int{{}: 1}), `{"k":{"!PANIC":"boom","type":"map[field.testPanicKey]int"}}`, false},
+	}
+	for _, testItem := range tests {
+		t.Run(testItem.name, func(t *testing.T) {
+			var fields = Fields{testItem.field}
+			var buf bytes.Buffer
+			if err := fields.EncodeJSON(&buf); err != nil || buf.String() != testItem.expect {
+				t.Errorf("invalid json: %s, expected: %s, %v", buf.String(), testItem.expect, err)
+				return
+			}
+			if result := fields.AppendJSON(nil); string(result) != testItem.expect {
+				t.Errorf("invalid appended json: %s, expected: %s", result, testItem.expect)
+				return
+			}
+			if result, err := (EncoderConfig{Order: KeyInsertion}).AppendJSON(nil, fields); err != nil || string(result) != testItem.expect {
+				t.Errorf("invalid configured json: %s, expected: %s, %v", result, testItem.expect, err)
+				return
+			}
+			if testItem.jsonOnly {
+				return
+			}
+			var encoders = map[string]func() ([]byte, error){
+				"logfmt":  fields.MarshalLogfmt,
+				"cbor":    fields.MarshalCBOR,
+				"msgpack": fields.MarshalMsgpack,
+				"console": func() ([]byte, error) {
+					var buf bytes.Buffer
+					var err = fields.EncodeConsole(&buf)
+					return buf.Bytes(), err
+				},
+			}
+			for name, encode := range encoders {
+				if result, err := encode(); err != nil || !bytes.Contains(result, []byte(panicKey)) {
+					t.Errorf("invalid %s result: %q, %v", name, result, err)
+					return
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,239 @@
+package field
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"fmt"
+	"path"
+	"regexp"
+	"sync/atomic"
+	"unicode/utf8"
+)
+
+const redactedText = "[REDACTED]"
+
+type RedactMode uint8
+
+const (
+	// RedactReplace replaces values with "[REDACTED]".
+	RedactReplace RedactMode = iota
+	// RedactHash replaces values with "sha256:" and the first 8 bytes of
+	// their digest in hex, equal values stay correlatable. Without HashKey
+	// the digest is unsalted, guessable values like emails, phone or card
+	// numbers can be recovered by hashing candidates; with it the digest is
+	// an HMAC-SHA256 written as "hmac-sha256:".
+	RedactHash
+	// RedactMask keeps the last runes of values like "****1234", values not
+	// longer than the kept part are masked entirely.
+	RedactMask
+)
+
+// defaultMaskKeep is the count of runes RedactMask keeps if Keep is unset.
+const defaultMaskKeep = 4
+
+// RedactRule matches fields by exact key, path.Match pattern of key, regular
+// expression of key or type of content; arrays match the type of their
+// elements. Values of matched fields are redacted by Mode, arrays are hashed
+// or masked element by element. HashKey is the secret key of RedactHash.
+type RedactRule struct {
+	Keys    []string
+	Globs   []string
+	Regexp  *regexp.Regexp
+	Types   []Type
+	Mode    RedactMode
+	Keep    int
+	HashKey []byte
+}
+
+func (r RedactRule) match(f Field) bool {
+	for i := 0; i < len(r.Keys); i++ {
+		if r.Keys[i] == f.Key {
+			return true
+		}
+	}
+	for i := 0; i < len(r.Globs); i++ {
+		if matched, err := path.Match(r.Globs[i], f.Key); err == nil && matched {
+			return true
+		}
+	}
+	if r.Regexp != nil && r.Regexp.MatchString(f.Key) {
+		return true
+	}
+	if len(r.Types) > 0 && f.Content != nil {
+		var typ = f.Content.Type()
+		for i := 0; i < len(r.Types); i++ {
+			if r.Types[i] == typ || r.Types[i] == typ&^TypeArray {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (r RedactRule) redact(content Content) Content {
+	if lazy, ok := content.(LazyContent); ok && r.Mode != RedactReplace {
+		content = lazy.Raw()
+	}
+	if redacted, ok := content.(RedactedContent); ok {
+		return redacted
+	}
+	if r.Mode == RedactReplace {
+		return RedactedContent{}
+	}
+	if array, ok := content.(ArrayContent); ok {
+		var list = make([]Content, len(array.arrayRaw))
+		for i := 0; i < len(list); i++ {
+			list[i] = r.redact(array.arrayRaw[i])
+		}
+		return ArrayContent{arrayRaw: list}
+	}
+	switch r.Mode {
+	case RedactHash:
+		if len(r.HashKey) > 0 {
+			var mac = hmac.New(sha256.New, r.HashKey)
+			_, _ = mac.Write([]byte(redactSource(content)))
+			return RedactedContent{text: fmt.Sprintf("hmac-sha256:%x", mac.Sum(nil)[:8])}
+		}
+		var sum = sha256.Sum256([]byte(redactSource(content)))
+		return RedactedContent{text: fmt.Sprintf("sha256:%x", sum[:8])}
+	case RedactMask:
+		var keep = r.Keep
+		if keep <= 0 {
+			keep = defaultMaskKeep
+		}
+		var text = redactSource(content)
+		if utf8.RuneCountInString(text) <= keep {
+			return RedactedContent{text: "****"}
+		}
+		var cut = len(text)
+		for ; keep > 0; keep-- {
+			var _, size = utf8.DecodeLastRuneInString(text[:cut])
+			cut -= size
+		}
+		return RedactedContent{text: "****" + text[cut:]}
+	}
+	return RedactedContent{}
+}
+
+// redactSource returns the text hashed or masked for content, strings are
+// taken as is and others as their JSON text.
+func redactSource(content Content) string {
+	switch v := content.(type) {
+	case nil:
+		return "null"
+	case StringContent:
+		return string(v)
+	case ErrorContent:
+		if v.data != nil {
+			if text, panicked := SafeError(v.data); panicked == nil {
+				return text
+			}
+		}
+	case StringerContent:
+		if v.data != nil {
+			if text, panicked := SafeString(v.data); panicked == nil {
+				return text
+			}
+		}
+	}
+	var buf bytes.Buffer
+	if err := content.EncodeJSON(&buf); err != nil {
+		return ""
+	}
+	return buf.String()
+}
+
+// RedactionPolicy is a list of rules, the first rule matching a field
+// decides how its value is redacted.
+type RedactionPolicy []RedactRule
+
+// Redact returns f with its value redacted if any rule matched.
+func (p RedactionPolicy) Redact(f Field) Field {
+	for i := 0; i < len(p); i++ {
+		if p[i].match(f) {
+			return Field{Key: f.Key, Content: p[i].redact(f.Content)}
+		}
+	}
+	return f
+}
+
+var redactionPolicy atomic.Value
+
+// SetRedactionPolicy installs policy for Export, Snapshot and the encoders of
+// this package, an empty policy disables redaction.
+func SetRedactionPolicy(policy RedactionPolicy) {
+	redactionPolicy.Store(append(RedactionPolicy{}, policy...))
+}
+
+func loadRedactionPolicy() RedactionPolicy {
+	policy, _ := redactionPolicy.Load().(RedactionPolicy)
+	return policy
+}
+
+// redactFields redacts fields in place with the installed policy.
+func redactFields(fields []Field) []Field {
+	var policy = loadRedactionPolicy()
+	if len(policy) == 0 {
+		return fields
+	}
+	for i := 0; i < len(fields); i++ {
+		fields[i] = policy.Redact(fields[i])
+	}
+	return fields
+}
+
+// Snapshot returns unique fields of f with the redaction policy and the size
+// limits applied, which is what the encoders of this package write. Adapters
+// converting fields for other loggers should convert the snapshot.
+func (f Fields) Snapshot() []Field { return loadSizeLimits().limitFields(redactFields(f.Unique())) }
+
+// snapshot returns f with the redaction policy and the size limits applied,
+// like Fields.Snapshot does for each field.
+func (f Field) snapshot() Field { return loadSizeLimits().limitField(loadRedactionPolicy().Redact(f)) }
+
+// RedactedContent hides a value from every encoder and fmt verb, it is
+// written as "[REDACTED]" and only Raw returns the value. Redaction policies
+// produce it as well, written as the hash or mask and holding no value. It is
+// never redacted again, so a hash stays the same on every export path.
+type RedactedContent struct {
+	data any
+	text string
+}
+
+func NewRedactedContent(val any) Content { return RedactedContent{data: val} }
+
+func (f RedactedContent) Type() Type { return TypeString }
+
+func (f RedactedContent) Data() any { return f.String() }
+
+func (f RedactedContent) Raw() any { return f.data }
+
+func (f RedactedContent) String() string {
+	if f.text == "" {
+		return redactedText
+	}
+	return f.text
+}
+
+func (f RedactedContent) GoString() string { return f.String() }
+
+func (f RedactedContent) Format(state fmt.State, _ rune) {
+	_, _ = state.Write([]byte(f.String()))
+}
+
+func (f RedactedContent) AppendJSON(dst []byte) []byte { return appendString(dst, f.String(), false) }
+
+func (f RedactedContent) EncodeJSON(buffer Buffer) error {
+	return errWithoutVal(buffer.Write(f.AppendJSON(nil)))
+}
+
+// MarshalJSON lets encoders of other packages, like slog.JSONHandler, write
+// the content held by slog.Value as its text.
+func (f RedactedContent) MarshalJSON() ([]byte, error) { return f.AppendJSON(nil), nil }
+
+func (f RedactedContent) EncodeLogfmt(buffer Buffer) error {
+	return writeLogfmtValue(buffer, f.String())
+}
+
+func Redacted(key string, val any) Field { return Field{Key: key, Content: NewRedactedContent(val)} }
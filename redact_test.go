@@ -0,0 +1,150 @@
+package field
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestRedactionPolicy(t *testing.T) {
+	var policy = RedactionPolicy{
+		{Keys: []string{"password"}},
+		{Globs: []string{"*_token"}, Mode: RedactHash},
+		{Globs: []string{"*_email"}, Mode: RedactHash, HashKey: []byte("pepper")},
+		{Regexp: regexp.MustCompile(`^card`), Mode: RedactMask},
+		{Types: []Type{TypeError}, Mode: RedactMask, Keep: 2},
+	}
+	var tests = []struct {
+		name   string
+		field  Field
+		expect string
+	}{
+		{"Key", String("password", "hunter2"), `{"password":"[REDACTED]"}`},
+		{"Glob", String("api_token", "secret"), `{"api_token":"sha256:2bb80d537b1da3e3"}`},
+		{"HashKey", String("user_email", "secret"), `{"user_email":"hmac-sha256:804e6d854739162f"}`},
+		{"Regexp", String("card_number", "4111111111111234"), `{"card_number":"****1234"}`},
+		{"MaskShort", String("card_cvv", "123"), `{"card_cvv":"****"}`},
+		{"MaskNonString", Int("card_id", 123456), `{"card_id":"****3456"}`},
+		{"Type", Error("err", errors.New("boom")), `{"err":"****om"}`},
+		{"TypeArray", Errors("errs", []error{errors.New("boom"), errors.New("a")}), `{"errs":["****om","****"]}`},
+		{"MaskArray", Strings("card_numbers", []string{"4111111111111234", "55"}), `{"card_numbers":["****1234","****"]}`},
+		{"Unmatched", String("user", "alice"), `{"user":"alice"}`},
+	}
+	for _, testItem := range tests {
+		t.Run(testItem.name, func(t *testing.T) {
+			var result, _ = Fields{policy.Redact(testItem.field)}.MarshalJSON()
+			if string(result) != testItem.expect {
+				t.Errorf("invalid redacted field: %s, expected: %s", result, testItem.expect)
+				return
+			}
+		})
+	}
+}
+
+func TestSetRedactionPolicy(t *testing.T) {
+	SetRedactionPolicy(RedactionPolicy{{Keys: []string{"token"}}})
+	defer SetRedactionPolicy(nil)
+	var fields = Fields{String("token", "abc"), Object("nested", String("token", "def"), Int("n", 1))}
+	var buf bytes.Buffer
+	if err := fields.EncodeJSON(&buf); err != nil {
+		t.Errorf("cant encode fields: %v", err)
+		return
+	}
+	if result, expected := buf.String(), `{"nested":{"n":1,"token":"[REDACTED]"},"token":"[REDACTED]"}`; result != expected {
+		t.Errorf("invalid json: %s, expected: %s", result, expected)
+		return
+	}
+	var exported = fields.Export()
+	if exported["token"] != "[REDACTED]" || exported["nested"].(map[string]any)["token"] != "[REDACTED]" {
+		t.Errorf("invalid export: %v", exported)
+		return
+	}
+	buf.Reset()
+	if err := fields.EncodeLogfmt(&buf); err != nil || strings.Contains(buf.String(), "abc") {
+		t.Errorf("invalid logfmt: %s, %v", buf.String(), err)
+		return
+	}
+}
+
+func TestFieldRedaction(t *testing.T) {
+	SetRedactionPolicy(RedactionPolicy{{Keys: []string{"password"}}, {Keys: []string{"token"}, Mode: RedactHash}})
+	defer SetRedactionPolicy(nil)
+	var field = String("password", "hunter2")
+	var encoders = map[string]func() ([]byte, error){
+		"MarshalJSON": field.MarshalJSON,
+		"AppendJSON":  func() ([]byte, error) { return field.AppendJSON(nil), nil },
+		"EncodeJSON": func() ([]byte, error) {
+			var buf bytes.Buffer
+			var err = field.EncodeJSON(&buf)
+			return buf.Bytes(), err
+		},
+		"EncodeLogfmt": func() ([]byte, error) {
+			var buf bytes.Buffer
+			var err = field.EncodeLogfmt(&buf)
+			return buf.Bytes(), err
+		},
+		"MarshalMsgpack": field.MarshalMsgpack,
+		"Snapshot": func() ([]byte, error) {
+			return []byte(Fields{field}.Snapshot()[0].Data().(string)), nil
+		},
+	}
+	for name, encode := range encoders {
+		t.Run(name, func(t *testing.T) {
+			var result, err = encode()
+			if err != nil {
+				t.Errorf("cant encode: %v", err)
+				return
+			}
+			if bytes.Contains(result, []byte("hunter2")) || !bytes.Contains(result, []byte(redactedText)) {
+				t.Errorf("invalid redacted result: %q", result)
+				return
+			}
+		})
+	}
+	// fields are redacted once, hashing a hash would lose correlation
+	var expected = `{"token":"` + string(RedactRule{Mode: RedactHash}.redact(StringContent("abc")).(RedactedContent).String()) + `"}`
+	for _, result := range []func() ([]byte, error){Fields{String("token", "abc")}.MarshalJSON, String("token", "abc").MarshalJSON} {
+		if data, _ := result(); string(data) != expected {
+			t.Errorf("invalid hashed field: %s, expected: %s", data, expected)
+			return
+		}
+	}
+}
+
+func TestRedactedField(t *testing.T) {
+	var field = Redacted("secret", "hunter2")
+	var fields = Fields{field}
+	var encoders = map[string]func() ([]byte, error){
+		"JSON":    fields.MarshalJSON,
+		"Logfmt":  fields.MarshalLogfmt,
+		"CBOR":    fields.MarshalCBOR,
+		"Msgpack": fields.MarshalMsgpack,
+		"Console": func() ([]byte, error) {
+			var buf bytes.Buffer
+			var err = fields.EncodeConsole(&buf)
+			return buf.Bytes(), err
+		},
+		"Fmt": func() ([]byte, error) {
+			return []byte(fmt.Sprintf("%v %+v %#v %s", field, field, field, field.Content)), nil
+		},
+	}
+	for name, encode := range encoders {
+		t.Run(name, func(t *testing.T) {
+			var result, err = encode()
+			if err != nil {
+				t.Errorf("cant encode: %v", err)
+				return
+			}
+			if bytes.Contains(result, []byte("hunter2")) || !bytes.Contains(result, []byte(redactedText)) {
+				t.Errorf("invalid redacted result: %q", result)
+				return
+			}
+		})
+	}
+	if field.Data() != redactedText || field.Content.(RedactedContent).Raw() != "hunter2" {
+		t.Errorf("invalid redacted content: %v", field.Data())
+	}
+}
@@ -0,0 +1,465 @@
+package field
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// maxReflectDepth bounds nesting of values walked by reflection, which also
+// stops self-referencing pointers from recursing forever.
+const maxReflectDepth = 32
+
+// reflectEncoder converts a value of a certain type to Content, depth is the
+// count of nested values walked so far.
+type reflectEncoder func(val reflect.Value, depth int) Content
+
+var reflectEncoders sync.Map // map[reflect.Type]reflectEncoder
+
+func typeEncoder(t reflect.Type) reflectEncoder {
+	if t == nil {
+		return nil
+	}
+	if encoder, ok := reflectEncoders.Load(t); ok {
+		return encoder.(reflectEncoder)
+	}
+	var encoder, _ = reflectEncoders.LoadOrStore(t, newTypeEncoder(t))
+	return encoder.(reflectEncoder)
+}
+
+func newTypeEncoder(t reflect.Type) reflectEncoder {
+	switch t.Kind() {
+	case reflect.Bool:
+		return func(val reflect.Value, _ int) Content { return BoolContent(val.Bool()) }
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return func(val reflect.Value, _ int) Content { return NewIntContent(val.Int()) }
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return func(val reflect.Value, _ int) Content { return NewUintContent(val.Uint()) }
+	case reflect.Uintptr:
+		return func(val reflect.Value, _ int) Content { return UintptrContent(val.Uint()) }
+	case reflect.Float32:
+		return func(val reflect.Value, _ int) Content { return Float32Content(val.Float()) }
+	case reflect.Float64:
+		return func(val reflect.Value, _ int) Content { return Float64Content(val.Float()) }
+	case reflect.Complex64:
+		return func(val reflect.Value, _ int) Content { return Complex64Content(val.Complex()) }
+	case reflect.Complex128:
+		return func(val reflect.Value, _ int) Content { return Complex128Content(val.Complex()) }
+	case reflect.String:
+		return func(val reflect.Value, _ int) Content { return StringContent(val.String()) }
+	case reflect.Interface, reflect.Pointer:
+		return func(val reflect.Value, depth int) Content {
+			if val.IsNil() {
+				return NilContent{}
+			}
+			return reflectContent(val.Elem(), depth)
+		}
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return func(val reflect.Value, _ int) Content {
+				if val.IsNil() {
+					return NilContent{}
+				}
+				return BinaryContent{binaryRaw: val.Bytes()}
+			}
+		}
+		var encodeArray = newArrayEncoder(t.Elem())
+		return func(val reflect.Value, depth int) Content {
+			if val.IsNil() {
+				return NilContent{}
+			}
+			return encodeArray(val, depth)
+		}
+	case reflect.Array:
+		return newArrayEncoder(t.Elem())
+	case reflect.Struct:
+		return newStructEncoder(t)
+	case reflect.Map:
+		return newMapEncoder(t)
+	default:
+		return nil
+	}
+}
+
+// isBuiltinType reports whether values of t can skip Any, it holds for
+// unnamed basic types which Any has no special case for besides its kind.
+func isBuiltinType(t reflect.Type) bool {
+	if t.PkgPath() != "" || t.Name() == "" {
+		return false
+	}
+	switch t.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128:
+		return true
+	default:
+		return false
+	}
+}
+
+// elemEncoder returns encoder for nested values of type t, which take the
+// same path as Any unless t is a builtin type.
+func elemEncoder(t reflect.Type) reflectEncoder {
+	if isBuiltinType(t) {
+		return typeEncoder(t)
+	}
+	return reflectContent
+}
+
+func reflectContent(val reflect.Value, depth int) Content {
+	if !val.IsValid() {
+		return NilContent{}
+	}
+	if depth++; depth > maxReflectDepth {
+		return NewErrorContent(fmt.Errorf("cant marshal field: exceeded max depth %d", maxReflectDepth))
+	}
+	if val.CanInterface() {
+		return anyField("", val.Interface(), depth).Content
+	}
+	if encoder := typeEncoder(val.Type()); encoder != nil {
+		return encoder(val, depth)
+	}
+	return NewErrorContent(fmt.Errorf("cant marshal field: no type matched"))
+}
+
+func newArrayEncoder(elemType reflect.Type) reflectEncoder {
+	var encodeElem = elemEncoder(elemType)
+	return func(val reflect.Value, depth int) Content {
+		var result = make([]Content, val.Len())
+		for i := 0; i < len(result); i++ {
+			result[i] = encodeElem(val.Index(i), depth)
+		}
+		return ArrayContent{arrayRaw: result}
+	}
+}
+
+func newMapEncoder(t reflect.Type) reflectEncoder {
+	var formatKey, encodeElem = mapKeyFormatter(t.Key()), elemEncoder(t.Elem())
+	return func(val reflect.Value, depth int) Content {
+		if val.IsNil() {
+			return NilContent{}
+		}
+		var entries = make([]mapEntry, 0, val.Len())
+		var iter = val.MapRange()
+		for iter.Next() {
+			entries = append(entries, mapEntry{
+				key:   iter.Key(),
+				field: Field{Key: formatKey(iter.Key()), Content: encodeElem(iter.Value(), depth)},
+			})
+		}
+		return ObjectContent{fields: sortMapEntries(entries)}
+	}
+}
+
+type mapEntry struct {
+	key   reflect.Value
+	field Field
+}
+
+// sortMapEntries orders entries by key text, entries whose keys collide in
+// text, like NaN keys or keys of equal MarshalText, by their original keys
+// and then by value. Which of them Unique keeps thus does not depend on the
+// random order of map iteration.
+func sortMapEntries(entries []mapEntry) Fields {
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].field.Key != entries[j].field.Key {
+			return entries[i].field.Key < entries[j].field.Key
+		}
+		if c := compareMapKeys(entries[i].key, entries[j].key); c != 0 {
+			return c < 0
+		}
+		return string(appendContentJSON(nil, entries[i].field.Content)) < string(appendContentJSON(nil, entries[j].field.Content))
+	})
+	var fields = make(Fields, len(entries))
+	for i := 0; i < len(entries); i++ {
+		fields[i] = entries[i].field
+	}
+	return fields
+}
+
+// compareMapKeys orders map keys by dynamic type name, then by value; NaN
+// sorts first and equals NaN.
+func compareMapKeys(a, b reflect.Value) int {
+	if a.Kind() == reflect.Interface {
+		a = a.Elem()
+	}
+	if b.Kind() == reflect.Interface {
+		b = b.Elem()
+	}
+	if !a.IsValid() || !b.IsValid() {
+		return compareOrdered(boolRank(a.IsValid()), boolRank(b.IsValid()))
+	}
+	if a.Type() != b.Type() {
+		return strings.Compare(a.Type().String(), b.Type().String())
+	}
+	switch a.Kind() {
+	case reflect.Bool:
+		return compareOrdered(boolRank(a.Bool()), boolRank(b.Bool()))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return compareOrdered(a.Int(), b.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return compareOrdered(a.Uint(), b.Uint())
+	case reflect.Float32, reflect.Float64:
+		return compareFloat(a.Float(), b.Float())
+	case reflect.Complex64, reflect.Complex128:
+		if c := compareFloat(real(a.Complex()), real(b.Complex())); c != 0 {
+			return c
+		}
+		return compareFloat(imag(a.Complex()), imag(b.Complex()))
+	case reflect.String:
+		return strings.Compare(a.String(), b.String())
+	case reflect.Pointer:
+		if a.IsNil() || b.IsNil() {
+			return compareOrdered(boolRank(!a.IsNil()), boolRank(!b.IsNil()))
+		}
+		return compareMapKeys(a.Elem(), b.Elem())
+	case reflect.Struct:
+		for i := 0; i < a.NumField(); i++ {
+			if c := compareMapKeys(a.Field(i), b.Field(i)); c != 0 {
+				return c
+			}
+		}
+	case reflect.Array:
+		for i := 0; i < a.Len(); i++ {
+			if c := compareMapKeys(a.Index(i), b.Index(i)); c != 0 {
+				return c
+			}
+		}
+	}
+	return 0
+}
+
+func compareFloat(a, b float64) int {
+	if a != a || b != b {
+		return compareOrdered(boolRank(a == a), boolRank(b == b))
+	}
+	return compareOrdered(a, b)
+}
+
+func compareOrdered[T int64 | uint64 | float64](a, b T) int {
+	if a < b {
+		return -1
+	}
+	if a > b {
+		return 1
+	}
+	return 0
+}
+
+func boolRank(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+var textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+// mapKeyFormatter follows encoding/json on choosing how map keys of type t
+// are stringified, but also accepts float, bool and other kinds of keys.
+func mapKeyFormatter(t reflect.Type) func(reflect.Value) string {
+	if t.Kind() == reflect.String {
+		return reflect.Value.String
+	}
+	if t.Implements(textMarshalerType) {
+		return func(val reflect.Value) string {
+			if val.Kind() == reflect.Pointer && val.IsNil() {
+				return ""
+			}
+			if text, err := val.Interface().(encoding.TextMarshaler).MarshalText(); err == nil {
+				return string(text)
+			}
+			return fmt.Sprint(val.Interface())
+		}
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return func(val reflect.Value) string { return strconv.FormatInt(val.Int(), 10) }
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return func(val reflect.Value) string { return strconv.FormatUint(val.Uint(), 10) }
+	case reflect.Float32, reflect.Float64:
+		return func(val reflect.Value) string { return strconv.FormatFloat(val.Float(), 'f', -1, t.Bits()) }
+	case reflect.Bool:
+		return func(val reflect.Value) string { return strconv.FormatBool(val.Bool()) }
+	default:
+		return func(val reflect.Value) string {
+			if !val.CanInterface() {
+				return val.String()
+			}
+			return fmt.Sprint(val.Interface())
+		}
+	}
+}
+
+type structField struct {
+	name      string
+	tagged    bool
+	index     []int
+	omitEmpty bool
+	encode    reflectEncoder
+}
+
+func newStructEncoder(t reflect.Type) reflectEncoder {
+	var fields = collectStructFields(t)
+	return func(val reflect.Value, depth int) Content {
+		var result = make(Fields, 0, len(fields))
+		for i := 0; i < len(fields); i++ {
+			var fieldVal, ok = fieldByIndex(val, fields[i].index)
+			if !ok || (fields[i].omitEmpty && isEmptyValue(fieldVal)) {
+				continue
+			}
+			result = append(result, Field{Key: fields[i].name, Content: fields[i].encode(fieldVal, depth)})
+		}
+		return ObjectContent{fields: result}
+	}
+}
+
+// collectStructFields lists exported fields of t following the rules of
+// encoding/json: fields of untagged embedded structs are promoted level by
+// level, each struct type is walked once, and of fields sharing a name the
+// shallowest wins, a tagged one if several are equally shallow; fields tied
+// on both are all dropped.
+func collectStructFields(t reflect.Type) []structField {
+	type embeddedStruct struct {
+		typ   reflect.Type
+		index []int
+	}
+	var fields []structField
+	var current, next = []embeddedStruct{}, []embeddedStruct{{typ: t}}
+	var count, nextCount = map[reflect.Type]int{}, map[reflect.Type]int{}
+	var visited = map[reflect.Type]bool{}
+	for len(next) > 0 {
+		current, next = next, current[:0]
+		count, nextCount = nextCount, map[reflect.Type]int{}
+		for _, es := range current {
+			if visited[es.typ] {
+				continue
+			}
+			visited[es.typ] = true
+			for i := 0; i < es.typ.NumField(); i++ {
+				var sf = es.typ.Field(i)
+				var tag = sf.Tag.Get("json")
+				if tag == "-" {
+					continue
+				}
+				var name, opts, _ = strings.Cut(tag, ",")
+				var fieldIndex = append(append(make([]int, 0, len(es.index)+1), es.index...), i)
+				if sf.Anonymous && name == "" {
+					var ft = sf.Type
+					if ft.Kind() == reflect.Pointer {
+						ft = ft.Elem()
+					}
+					if ft.Kind() == reflect.Struct {
+						if nextCount[ft]++; nextCount[ft] == 1 {
+							next = append(next, embeddedStruct{typ: ft, index: fieldIndex})
+						}
+						continue
+					}
+				}
+				if !sf.IsExported() {
+					continue
+				}
+				var field = structField{
+					name:      name,
+					tagged:    name != "",
+					index:     fieldIndex,
+					omitEmpty: hasTagOption(opts, "omitempty"),
+					encode:    elemEncoder(sf.Type),
+				}
+				if !field.tagged {
+					field.name = sf.Name
+				}
+				fields = append(fields, field)
+				if count[es.typ] > 1 {
+					// a struct embedded twice at the same level, the copy
+					// makes its fields tie and get dropped
+					fields = append(fields, field)
+				}
+			}
+		}
+	}
+	sort.Slice(fields, func(i, j int) bool {
+		if fields[i].name != fields[j].name {
+			return fields[i].name < fields[j].name
+		}
+		if len(fields[i].index) != len(fields[j].index) {
+			return len(fields[i].index) < len(fields[j].index)
+		}
+		if fields[i].tagged != fields[j].tagged {
+			return fields[i].tagged
+		}
+		return indexLess(fields[i].index, fields[j].index)
+	})
+	var dominant = fields[:0]
+	for i := 0; i < len(fields); {
+		var group = 1
+		for i+group < len(fields) && fields[i+group].name == fields[i].name {
+			group++
+		}
+		if group == 1 || len(fields[i].index) != len(fields[i+1].index) || fields[i].tagged != fields[i+1].tagged {
+			dominant = append(dominant, fields[i])
+		}
+		i += group
+	}
+	sort.Slice(dominant, func(i, j int) bool { return indexLess(dominant[i].index, dominant[j].index) })
+	return dominant
+}
+
+// indexLess orders field indexes by declaration, like encoding/json.
+func indexLess(a, b []int) bool {
+	for k := 0; k < len(a) && k < len(b); k++ {
+		if a[k] != b[k] {
+			return a[k] < b[k]
+		}
+	}
+	return len(a) < len(b)
+}
+
+func hasTagOption(opts string, option string) bool {
+	for opts != "" {
+		var opt string
+		opt, opts, _ = strings.Cut(opts, ",")
+		if opt == option {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldByIndex is like reflect.Value.FieldByIndex, but reports false instead
+// of panicking when walking through a nil embedded pointer.
+func fieldByIndex(val reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && val.Kind() == reflect.Pointer {
+			if val.IsNil() {
+				return reflect.Value{}, false
+			}
+			val = val.Elem()
+		}
+		val = val.Field(x)
+	}
+	return val, true
+}
+
+func isEmptyValue(val reflect.Value) bool {
+	switch val.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return val.Len() == 0
+	case reflect.Bool:
+		return !val.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return val.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return val.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return val.Float() == 0
+	case reflect.Interface, reflect.Pointer:
+		return val.IsNil()
+	}
+	return false
+}
@@ -0,0 +1,247 @@
+package field
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testReflectEmbedded struct {
+	Region string `json:"region"`
+	Name   string `json:"embeddedName"`
+}
+
+type testReflectStruct struct {
+	testReflectEmbedded
+	Name     string        `json:"name"`
+	Age      int           `json:"age,omitempty"`
+	Tags     []string      `json:"tags,omitempty"`
+	Skipped  string        `json:"-"`
+	Dash     string        `json:"-,"`
+	Untagged bool          // keeps field name
+	Timeout  time.Duration `json:"timeout"`
+	Parent   *testReflectStruct
+	Extra    any `json:"extra"`
+	hidden   string
+}
+
+type testReflectNode struct {
+	Next *testReflectNode
+}
+
+type testReflectSelf struct {
+	*testReflectSelf
+	A int
+}
+
+type testReflectTieA struct{ Shared, OnlyA int }
+
+type testReflectTieB struct{ Shared, OnlyB int }
+
+type testReflectTagA struct {
+	Name string `json:"Tagged"`
+}
+
+type testReflectTagB struct{ Tagged string }
+
+type testReflectDominance struct {
+	testReflectTieA
+	testReflectTieB
+	testReflectTagA
+	testReflectTagB
+	OnlyA string
+}
+
+func TestReflectStructField(t *testing.T) {
+	t.Run("json", func(t *testing.T) {
+		var val = testReflectStruct{
+			testReflectEmbedded: testReflectEmbedded{Region: "cn", Name: "shadowed"},
+			Name:                "alice",
+			Skipped:             "x",
+			Dash:                "y",
+			Timeout:             time.Second,
+			Parent:              &testReflectStruct{Name: "bob", Age: 3},
+			Extra:               []int{1, 2},
+			hidden:              "z",
+		}
+		var buf bytes.Buffer
+		if err := Any("case1", val).EncodeJSON(&buf); err != nil {
+			t.Error(fmt.Errorf("cant marshal struct Field: %w", err))
+			return
+		}
+		var expected = `"case1":{"-":"y","Parent":{"-":"","Parent":null,"Untagged":false,"age":3,"embeddedName":"","extra":null,"name":"bob","region":"","timeout":"0s"},` +
+			`"Untagged":false,"embeddedName":"shadowed","extra":[1,2],"name":"alice","region":"cn","timeout":"1s"}`
+		if result := buf.String(); result != expected {
+			t.Errorf("invalid marshal struct result: `%s`, expected: `%s`", result, expected)
+			return
+		}
+	})
+	t.Run("data", func(t *testing.T) {
+		var expected = map[string]any{"region": "cn", "embeddedName": "", "name": "", "-": "", "Untagged": true, "timeout": time.Duration(0), "Parent": nil, "extra": nil}
+		if data := Any("k", &testReflectStruct{testReflectEmbedded: testReflectEmbedded{Region: "cn"}, Untagged: true}).Data(); !reflect.DeepEqual(data, expected) {
+			t.Errorf("invalid struct data: %#v", data)
+			return
+		}
+	})
+	t.Run("cycle", func(t *testing.T) {
+		var node = &testReflectNode{}
+		node.Next = node
+		var buf bytes.Buffer
+		if err := Any("k", node).EncodeJSON(&buf); err != nil {
+			t.Error(err)
+			return
+		}
+		if !bytes.Contains(buf.Bytes(), []byte("exceeded max depth")) {
+			t.Errorf("cycle not bounded: %s", buf.String())
+			return
+		}
+	})
+	t.Run("namedKinds", func(t *testing.T) {
+		type level int
+		type names []string
+		var buf bytes.Buffer
+		if err := (Fields{Any("level", level(3)), Any("names", names{"a"})}).EncodeJSON(&buf); err != nil {
+			t.Error(err)
+			return
+		}
+		if result := buf.String(); result != `{"level":3,"names":["a"]}` {
+			t.Errorf("invalid marshal named kinds result: `%s`", result)
+			return
+		}
+	})
+	t.Run("embedded", func(t *testing.T) {
+		var tests = []struct {
+			name string
+			val  any
+		}{
+			{"self", testReflectSelf{testReflectSelf: &testReflectSelf{A: 2}, A: 1}},
+			{"dominance", testReflectDominance{
+				testReflectTieA: testReflectTieA{Shared: 1, OnlyA: 2},
+				testReflectTieB: testReflectTieB{Shared: 3, OnlyB: 4},
+				testReflectTagA: testReflectTagA{Name: "tagged"},
+				testReflectTagB: testReflectTagB{Tagged: "untagged"},
+				OnlyA:           "shallow",
+			}},
+		}
+		for _, testItem := range tests {
+			t.Run(testItem.name, func(t *testing.T) {
+				var expected, result map[string]any
+				var data, _ = json.Marshal(testItem.val)
+				if err := json.Unmarshal(data, &expected); err != nil {
+					t.Error(err)
+					return
+				}
+				if err := json.Unmarshal(Fields{Any("k", testItem.val)}.AppendJSON(nil), &result); err != nil {
+					t.Error(err)
+					return
+				}
+				if !reflect.DeepEqual(result["k"], expected) {
+					t.Errorf("invalid embedded struct: %v, expected: %v", result["k"], expected)
+					return
+				}
+			})
+		}
+	})
+	t.Run("cached", func(t *testing.T) {
+		var first = typeEncoder(reflect.TypeOf(testReflectStruct{}))
+		var second = typeEncoder(reflect.TypeOf(testReflectStruct{}))
+		if reflect.ValueOf(first).Pointer() != reflect.ValueOf(second).Pointer() {
+			t.Errorf("struct encoder not cached")
+			return
+		}
+	})
+}
+
+func BenchmarkReflectStruct(b *testing.B) {
+	var val = testReflectStruct{Name: "alice", Age: 3, Tags: []string{"a", "b"}}
+	var buf bytes.Buffer
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf.Reset()
+		_ = Any("k", val).EncodeJSON(&buf)
+	}
+}
+
+type testTextKey struct{ a, b int }
+
+func (k testTextKey) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("%d-%d", k.a, k.b)), nil
+}
+
+type testLowerKey struct{ s string }
+
+func (k testLowerKey) MarshalText() ([]byte, error) { return []byte(strings.ToLower(k.s)), nil }
+
+func TestMapField(t *testing.T) {
+	t.Run("newField", func(t *testing.T) {
+		var expected = map[string]any{"a": 1, "b": "x"}
+		if data := Map("k", map[string]any{"a": 1, "b": "x"}).Data(); !reflect.DeepEqual(data, expected) {
+			t.Errorf("invalid Map response: %v", data)
+			return
+		}
+		if typ := Any("k", map[int]bool{}).Type(); typ != TypeObject {
+			t.Errorf("invalid Map type: %v", typ)
+			return
+		}
+	})
+	t.Run("json", func(t *testing.T) {
+		var list = Fields{
+			Map("case1", map[string]any{"z": 1, "a": []string{"x"}, "m": map[string]string{"k": "v"}}),
+			Map("case2", map[int]float64{10: 1.5, -2: 0, 3: 2}),
+			Any("case3", map[testTextKey]bool{{1, 2}: true, {0, 1}: false}),
+			Any("case4", map[float64]uint8{1.25: 1}),
+			Any("case5", map[string]int(nil)),
+		}
+		var buf bytes.Buffer
+		if err := list.EncodeJSON(&buf); err != nil {
+			t.Error(fmt.Errorf("cant marshal map Field: %w", err))
+			return
+		}
+		var expected = `{"case1":{"a":["x"],"m":{"k":"v"},"z":1},"case2":{"-2":0,"10":1.5,"3":2},` +
+			`"case3":{"0-1":false,"1-2":true},"case4":{"1.25":1},"case5":null}`
+		if result := buf.String(); result != expected {
+			t.Errorf("invalid marshal map result: `%s`, expected: `%s`", result, expected)
+			return
+		}
+	})
+	t.Run("collision", func(t *testing.T) {
+		var tests = []struct {
+			name   string
+			field  Field
+			expect string
+		}{
+			{"nan", Any("k", map[float64]int{math.NaN(): 2, math.NaN(): 1, 1: 3}), `"k":{"1":3,"NaN":1}`},
+			{"text", Any("k", map[testLowerKey]int{{"a"}: 2, {"A"}: 1, {"b"}: 3}), `"k":{"a":1,"b":3}`},
+			{"textPointer", Map("k", map[*testLowerKey]int{{"a"}: 2, {"A"}: 1, nil: 0}), `"k":{"":0,"a":1}`},
+			{"types", Any("k", map[any]any{1: "int", "1": "string", 1.0: "float"}), `"k":{"1":"float"}`},
+		}
+		for _, testItem := range tests {
+			t.Run(testItem.name, func(t *testing.T) {
+				for i := 0; i < 20; i++ {
+					if result := string(testItem.field.AppendJSON(nil)); result != testItem.expect {
+						t.Errorf("invalid map result: %s, expected: %s", result, testItem.expect)
+						return
+					}
+				}
+			})
+		}
+	})
+	t.Run("cycle", func(t *testing.T) {
+		var m = map[string]any{}
+		m["self"] = m
+		var buf bytes.Buffer
+		if err := Any("k", m).EncodeJSON(&buf); err != nil {
+			t.Error(err)
+			return
+		}
+		if !bytes.Contains(buf.Bytes(), []byte("exceeded max depth")) {
+			t.Errorf("cycle not bounded: %s", buf.String())
+			return
+		}
+	})
+}
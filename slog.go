@@ -0,0 +1,314 @@
+//go:build go1.21
+
+package field
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// Attr converts field to slog.Attr, picking the slog.Value kind matching its
+// content. Arrays become slog.AnyValue of their Data, objects become groups.
+// The redaction policy and the size limits are applied like Fields.Snapshot.
+func (f Field) Attr() slog.Attr { return f.snapshot().attr() }
+
+func (f Field) attr() slog.Attr { return slog.Attr{Key: f.Key, Value: contentSlogValue(f.Content)} }
+
+// Attrs converts unique fields to []slog.Attr.
+func (f Fields) Attrs() []slog.Attr {
+	var snap = f.Snapshot()
+	var attrs = make([]slog.Attr, len(snap))
+	for i := 0; i < len(snap); i++ {
+		attrs[i] = snap[i].attr()
+	}
+	return attrs
+}
+
+// LogValue implements slog.LogValuer, fields are logged as a group.
+func (f Fields) LogValue() slog.Value { return slog.GroupValue(f.Attrs()...) }
+
+func contentSlogValue(content Content) slog.Value {
+	switch v := content.(type) {
+	case nil, NilContent:
+		return slog.AnyValue(nil)
+	case BoolContent:
+		return slog.BoolValue(bool(v))
+	case Float32Content:
+		return slog.Float64Value(float64(v))
+	case Float64Content:
+		return slog.Float64Value(float64(v))
+	case StringContent:
+		return slog.StringValue(string(v))
+	case TimeContent:
+		return slog.TimeValue(time.Time(v))
+	case TimeFormatContent:
+		return slog.TimeValue(v.data)
+	case UintptrContent:
+		return slog.Uint64Value(uint64(v))
+	case StringerContent:
+		switch data := v.data.(type) {
+		case nil:
+			return slog.AnyValue(nil)
+		case time.Duration:
+			return slog.DurationValue(data)
+		default:
+			if text, panicked := SafeString(data); panicked != nil {
+				return contentSlogValue(panicked)
+			} else {
+				return slog.StringValue(text)
+			}
+		}
+	case ErrorContent:
+		if v.data == nil {
+			return slog.AnyValue(nil)
+		}
+		return slog.AnyValue(v.data)
+	case ObjectContent:
+		return v.fields.LogValue()
+	case RedactedContent:
+		// kept as is, so FromAttr knows it is redacted already
+		return slog.AnyValue(v)
+	case LazyContent:
+		return contentSlogValue(v.Raw())
+	case CallerContent:
+		return contentSlogValue(v.content())
+	}
+	switch content.Type() {
+	case TypeInt:
+		if i, ok := IntData(content); ok {
+			return slog.Int64Value(i)
+		}
+	case TypeUint:
+		if u, ok := UintData(content); ok {
+			return slog.Uint64Value(u)
+		}
+	}
+	return slog.AnyValue(content.Data())
+}
+
+// FromAttr converts slog.Attr to Field, groups become objects.
+func FromAttr(attr slog.Attr) Field {
+	var value = attr.Value.Resolve()
+	switch value.Kind() {
+	case slog.KindBool:
+		return Bool(attr.Key, value.Bool())
+	case slog.KindInt64:
+		return Int64(attr.Key, value.Int64())
+	case slog.KindUint64:
+		return Uint64(attr.Key, value.Uint64())
+	case slog.KindFloat64:
+		return Float64(attr.Key, value.Float64())
+	case slog.KindString:
+		return String(attr.Key, value.String())
+	case slog.KindTime:
+		return Time(attr.Key, value.Time())
+	case slog.KindDuration:
+		return Duration(attr.Key, value.Duration())
+	case slog.KindGroup:
+		return Object(attr.Key, FromAttrs(value.Group())...)
+	}
+	if redacted, ok := value.Any().(RedactedContent); ok {
+		return Field{Key: attr.Key, Content: redacted}
+	}
+	return Any(attr.Key, value.Any())
+}
+
+// FromAttrs converts []slog.Attr to Fields, following slog on ignoring empty
+// attrs and inlining groups of empty key.
+func FromAttrs(attrs []slog.Attr) Fields {
+	var fields = make(Fields, 0, len(attrs))
+	for _, attr := range attrs {
+		fields = appendSlogAttr(fields, attr)
+	}
+	return fields
+}
+
+func appendSlogAttr(fields Fields, attr slog.Attr) Fields {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return fields
+	}
+	if attr.Value.Kind() == slog.KindGroup {
+		var group = FromAttrs(attr.Value.Group())
+		if len(group) == 0 {
+			return fields
+		}
+		if attr.Key == "" {
+			return append(fields, group...)
+		}
+		return append(fields, Object(attr.Key, group...))
+	}
+	return append(fields, FromAttr(attr))
+}
+
+// FromRecord converts attrs of record to Fields, the time, level and message
+// of record are not included.
+func FromRecord(record slog.Record) Fields {
+	var fields = make(Fields, 0, record.NumAttrs())
+	record.Attrs(func(attr slog.Attr) bool {
+		fields = appendSlogAttr(fields, attr)
+		return true
+	})
+	return fields
+}
+
+// SlogHandler is a slog.Handler writing each record as a line encoded by
+// Fields.EncodeJSON, times in RFC 3339 with nanoseconds. Level, AddSource
+// and ReplaceAttr of slog.HandlerOptions are honoured. Attrs on top level
+// whose key is taken by the level, message, time or source are renamed
+// with a "fields." prefix.
+type SlogHandler struct {
+	writer io.Writer
+	lock   *sync.Mutex
+	opts   slog.HandlerOptions
+	groups []slogGroup
+}
+
+// slogGroup holds attrs added by WithAttrs after a WithGroup, the first one
+// of a handler is the root with empty name.
+type slogGroup struct {
+	name   string
+	fields Fields
+}
+
+func NewSlogHandler(writer io.Writer, opts *slog.HandlerOptions) *SlogHandler {
+	var handler = &SlogHandler{writer: writer, lock: &sync.Mutex{}, groups: []slogGroup{{}}}
+	if opts != nil {
+		handler.opts = *opts
+	}
+	return handler
+}
+
+func (h *SlogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	var minLevel = slog.LevelInfo
+	if h.opts.Level != nil {
+		minLevel = h.opts.Level.Level()
+	}
+	return level >= minLevel
+}
+
+func (h *SlogHandler) Handle(_ context.Context, record slog.Record) (err error) {
+	var last = len(h.groups) - 1
+	var attrs = make([]slog.Attr, 0, record.NumAttrs())
+	record.Attrs(func(attr slog.Attr) bool {
+		attrs = append(attrs, attr)
+		return true
+	})
+	var fields = append(append(Fields{}, h.groups[last].fields...), h.fromAttrs(h.groupNames(), attrs)...)
+	for i := last; i > 0; i-- {
+		var parent = h.groups[i-1].fields
+		if len(fields) > 0 {
+			parent = append(append(Fields{}, parent...), Object(h.groups[i].name, fields...))
+		}
+		fields = parent
+	}
+	var builtin = make(Fields, 0, 4)
+	builtin = h.appendBuiltin(builtin, slog.Any(slog.LevelKey, record.Level))
+	builtin = h.appendBuiltin(builtin, slog.String(slog.MessageKey, record.Message))
+	if !record.Time.IsZero() {
+		builtin = h.appendBuiltin(builtin, slog.Time(slog.TimeKey, record.Time))
+	}
+	if h.opts.AddSource && record.PC != 0 {
+		var frame, _ = runtime.CallersFrames([]uintptr{record.PC}).Next()
+		var source = &slog.Source{Function: frame.Function, File: frame.File, Line: frame.Line}
+		builtin = h.appendBuiltin(builtin, slog.Any(slog.SourceKey, source))
+	}
+	var all = append(builtin, fields...)
+	for i := len(builtin); i < len(all); i++ {
+		if builtin.Has(all[i].Key) {
+			all[i].Key = "fields." + all[i].Key
+		}
+	}
+	var buf bytes.Buffer
+	if err = all.EncodeJSON(&buf); err != nil {
+		return err
+	}
+	buf.WriteByte('\n')
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	_, err = h.writer.Write(buf.Bytes())
+	return err
+}
+
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	var clone = *h
+	var last = len(h.groups) - 1
+	clone.groups = append([]slogGroup{}, h.groups...)
+	clone.groups[last].fields = append(append(Fields{}, h.groups[last].fields...), h.fromAttrs(h.groupNames(), attrs)...)
+	return &clone
+}
+
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	var clone = *h
+	clone.groups = append(append([]slogGroup{}, h.groups...), slogGroup{name: name})
+	return &clone
+}
+
+// groupNames lists names of the groups opened by WithGroup.
+func (h *SlogHandler) groupNames() []string {
+	var names = make([]string, 0, len(h.groups)-1)
+	for i := 1; i < len(h.groups); i++ {
+		names = append(names, h.groups[i].name)
+	}
+	return names
+}
+
+// fromAttrs is FromAttrs passing attrs not being a group through
+// ReplaceAttr, groups lists the groups they are nested in.
+func (h *SlogHandler) fromAttrs(groups []string, attrs []slog.Attr) Fields {
+	if h.opts.ReplaceAttr == nil {
+		return FromAttrs(attrs)
+	}
+	var fields = make(Fields, 0, len(attrs))
+	for _, attr := range attrs {
+		attr.Value = attr.Value.Resolve()
+		if attr.Value.Kind() != slog.KindGroup {
+			if attr = h.opts.ReplaceAttr(groups, attr); attr.Key != "" {
+				fields = appendSlogAttr(fields, attr)
+			}
+			continue
+		}
+		if attr.Key == "" {
+			fields = append(fields, h.fromAttrs(groups, attr.Value.Group())...)
+		} else if group := h.fromAttrs(append(groups[:len(groups):len(groups)], attr.Key), attr.Value.Group()); len(group) > 0 {
+			fields = append(fields, Object(attr.Key, group...))
+		}
+	}
+	return fields
+}
+
+// appendBuiltin appends the level, message, time or source attr after
+// ReplaceAttr, which sees them like for the slog handlers: level as
+// slog.Level and source as *slog.Source.
+func (h *SlogHandler) appendBuiltin(fields Fields, attr slog.Attr) Fields {
+	if h.opts.ReplaceAttr != nil {
+		if attr = h.opts.ReplaceAttr(nil, attr); attr.Key == "" {
+			return fields
+		}
+	}
+	attr.Value = attr.Value.Resolve()
+	switch value := attr.Value.Any().(type) {
+	case slog.Level:
+		return append(fields, String(attr.Key, value.String()))
+	case time.Time:
+		return append(fields, TimeLayout(attr.Key, value, time.RFC3339Nano))
+	case *slog.Source:
+		return append(fields, Object(attr.Key,
+			String("function", value.Function),
+			String("file", value.File),
+			Int("line", value.Line),
+		))
+	}
+	return appendSlogAttr(fields, attr)
+}
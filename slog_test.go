@@ -0,0 +1,213 @@
+//go:build go1.21
+
+package field
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"reflect"
+	"testing"
+	"testing/slogtest"
+	"time"
+)
+
+func TestSlogAttr(t *testing.T) {
+	var timeVal = time.Unix(100, 0)
+	var testErr = errors.New("boom")
+	var tests = []struct {
+		name   string
+		field  Field
+		expect slog.Attr
+	}{
+		{"Nil", Nil("k"), slog.Any("k", nil)},
+		{"Bool", Bool("k", true), slog.Bool("k", true)},
+		{"Int8", Int8("k", -1), slog.Int64("k", -1)},
+		{"Uint16", Uint16("k", 1), slog.Uint64("k", 1)},
+		{"Float32", Float32("k", 1.5), slog.Float64("k", 1.5)},
+		{"String", String("k", "v"), slog.String("k", "v")},
+		{"Time", Time("k", timeVal), slog.Time("k", timeVal)},
+		{"Duration", Duration("k", time.Second), slog.Duration("k", time.Second)},
+		{"Error", Error("k", testErr), slog.Any("k", testErr)},
+		{"Strings", Strings("k", []string{"a"}), slog.Any("k", []any{"a"})},
+		{"Object", Object("k", Int("b", 2), Int("a", 1)), slog.Group("k", slog.Int64("a", 1), slog.Int64("b", 2))},
+	}
+	for _, testItem := range tests {
+		t.Run(testItem.name, func(t *testing.T) {
+			var attr = testItem.field.Attr()
+			if attr.Value.Kind() == slog.KindAny && reflect.DeepEqual(attr.Value.Any(), testItem.expect.Value.Any()) {
+				return
+			}
+			if !attr.Equal(testItem.expect) {
+				t.Errorf("invalid attr: %v, expected: %v", attr, testItem.expect)
+			}
+		})
+	}
+	t.Run("LogValuer", func(t *testing.T) {
+		var value = slog.AnyValue(Fields{String("a", "x")}).Resolve()
+		if value.Kind() != slog.KindGroup || !value.Equal(slog.GroupValue(slog.String("a", "x"))) {
+			t.Errorf("invalid log value: %v", value)
+		}
+	})
+	t.Run("Redaction", func(t *testing.T) {
+		SetRedactionPolicy(RedactionPolicy{{Keys: []string{"password"}}})
+		defer SetRedactionPolicy(nil)
+		if attr := String("password", "hunter2").Attr(); attr.Key != "password" || attr.Value.String() != redactedText {
+			t.Errorf("invalid attr: %v", attr)
+		}
+	})
+}
+
+func TestSlogFromAttrs(t *testing.T) {
+	var fields = FromAttrs([]slog.Attr{
+		slog.Int("int", 1),
+		{},
+		slog.Group("", slog.String("inline", "x")),
+		slog.Group("empty"),
+		slog.Group("group", slog.Bool("b", true), slog.Any("fields", Fields{Uint8("u", 1)})),
+		slog.Duration("duration", time.Second),
+		slog.Any("any", []int{1}),
+	})
+	var expected = Fields{
+		Int64("int", 1),
+		String("inline", "x"),
+		Object("group", Bool("b", true), Object("fields", Uint64("u", 1))),
+		Duration("duration", time.Second),
+		Ints("any", []int{1}),
+	}
+	if !reflect.DeepEqual(fields, expected) {
+		t.Errorf("invalid fields: %#v", fields)
+	}
+	var record = slog.NewRecord(time.Now(), slog.LevelInfo, "msg", 0)
+	record.AddAttrs(slog.String("a", "b"))
+	if fields = FromRecord(record); !reflect.DeepEqual(fields, Fields{String("a", "b")}) {
+		t.Errorf("invalid record fields: %#v", fields)
+	}
+}
+
+func TestSlogHandler(t *testing.T) {
+	var buf bytes.Buffer
+	var handler = NewSlogHandler(&buf, nil)
+	var results = func() (results []map[string]any) {
+		for _, line := range bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte{'\n'}) {
+			var m map[string]any
+			if err := json.Unmarshal(line, &m); err != nil {
+				t.Fatal(err)
+			}
+			results = append(results, m)
+		}
+		return results
+	}
+	if err := slogtest.TestHandler(handler, results); err != nil {
+		t.Error(err)
+	}
+	t.Run("line", func(t *testing.T) {
+		buf.Reset()
+		var logger = slog.New(NewSlogHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+		logger.With("user", "alice").WithGroup("req").Debug("done", "status", 200)
+		var expected = `{"level":"DEBUG","msg":"done","req":{"status":200},"time":`
+		if result := buf.String(); !bytes.HasPrefix(buf.Bytes(), []byte(expected)) || !bytes.HasSuffix(buf.Bytes(), []byte(`,"user":"alice"}`+"\n")) {
+			t.Errorf("invalid handler result: %s", result)
+		}
+	})
+	t.Run("collision", func(t *testing.T) {
+		buf.Reset()
+		slog.New(NewSlogHandler(&buf, nil)).With("level", "custom").Info("done", "msg", "user", "time", 1)
+		var result = results()[0]
+		if result["msg"] != "done" || result["fields.msg"] != "user" || result["level"] != "INFO" ||
+			result["fields.level"] != "custom" || result["fields.time"] != float64(1) {
+			t.Errorf("invalid handler result: %s", buf.String())
+		}
+	})
+	t.Run("replaceAttr", func(t *testing.T) {
+		buf.Reset()
+		var replace = func(groups []string, attr slog.Attr) slog.Attr {
+			switch {
+			case len(groups) == 0 && attr.Key == slog.TimeKey:
+				return slog.Attr{}
+			case len(groups) == 0 && attr.Key == slog.LevelKey:
+				return slog.String("severity", attr.Value.Any().(slog.Level).String())
+			case len(groups) == 2 && groups[1] == "inner" && attr.Key == "secret":
+				return slog.String(attr.Key, "***")
+			}
+			return attr
+		}
+		var logger = slog.New(NewSlogHandler(&buf, &slog.HandlerOptions{ReplaceAttr: replace}))
+		logger.WithGroup("req").With("secret", "a").Info("done", slog.Group("inner", "secret", "b"))
+		var expected = `{"msg":"done","req":{"inner":{"secret":"***"},"secret":"a"},"severity":"INFO"}` + "\n"
+		if buf.String() != expected {
+			t.Errorf("invalid handler result: %s, expected: %s", buf.String(), expected)
+		}
+	})
+	t.Run("timeNanos", func(t *testing.T) {
+		buf.Reset()
+		var record = slog.NewRecord(time.Date(2023, 5, 20, 23, 15, 16, 123456789, time.UTC), slog.LevelInfo, "done", 0)
+		if err := NewSlogHandler(&buf, nil).Handle(context.Background(), record); err != nil {
+			t.Error(err)
+			return
+		}
+		if result := results()[0]["time"]; result != "2023-05-20T23:15:16.123456789Z" {
+			t.Errorf("invalid handler time: %v", result)
+		}
+	})
+}
+
+func TestSlogRedactedOnce(t *testing.T) {
+	SetRedactionPolicy(RedactionPolicy{{Keys: []string{"token"}, Mode: RedactHash}})
+	defer SetRedactionPolicy(nil)
+	var hashed = RedactRule{Mode: RedactHash}.redact(StringContent("abc")).(RedactedContent).String()
+	var tests = map[string]func() ([]byte, error){
+		"EncodeJSON": func() ([]byte, error) {
+			var buf bytes.Buffer
+			var err = String("token", "abc").EncodeJSON(&buf)
+			return []byte("{" + buf.String() + "}"), err
+		},
+		"Snapshot": func() ([]byte, error) {
+			var buf bytes.Buffer
+			var err = Fields{String("token", "abc")}.Snapshot()[0].EncodeJSON(&buf)
+			return []byte("{" + buf.String() + "}"), err
+		},
+		"FromAttrs": func() ([]byte, error) {
+			return FromAttrs(Fields{String("token", "abc")}.Attrs()).MarshalJSON()
+		},
+		"SlogHandler": func() ([]byte, error) {
+			var buf bytes.Buffer
+			var logger = slog.New(NewSlogHandler(&buf, &slog.HandlerOptions{ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
+				if len(groups) == 0 && (attr.Key == slog.TimeKey || attr.Key == slog.LevelKey || attr.Key == slog.MessageKey) {
+					return slog.Attr{}
+				}
+				return attr
+			}}))
+			logger.Info("done", String("token", "abc").Attr(), slog.Any("req", Fields{String("token", "abc")}))
+			return bytes.TrimSpace(buf.Bytes()), nil
+		},
+		"JSONHandler": func() ([]byte, error) {
+			var buf bytes.Buffer
+			slog.New(slog.NewJSONHandler(&buf, nil)).Info("done", String("token", "abc").Attr())
+			var m map[string]any
+			var err = json.Unmarshal(buf.Bytes(), &m)
+			return []byte(`{"token":"` + m["token"].(string) + `"}`), err
+		},
+	}
+	var expected = map[string]string{
+		"SlogHandler": `{"req":{"token":"` + hashed + `"},"token":"` + hashed + `"}`,
+	}
+	for name, encode := range tests {
+		t.Run(name, func(t *testing.T) {
+			var result, err = encode()
+			if err != nil {
+				t.Errorf("cant encode: %v", err)
+				return
+			}
+			var want, ok = expected[name]
+			if !ok {
+				want = `{"token":"` + hashed + `"}`
+			}
+			if string(result) != want {
+				t.Errorf("invalid redacted result: %s, expected: %s", result, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package field
+
+import (
+	"runtime"
+	"strconv"
+)
+
+// maxStackDepth bounds how many frames Stack captures.
+const maxStackDepth = 64
+
+// StackContent holds program counters of a call stack, which are symbolized
+// only when encoded. JSON is an array like [{"func":"...","file":"...","line":1}],
+// text encoders write a line of `func file:line` for each frame.
+type StackContent struct {
+	pcs []uintptr
+}
+
+// NewStackContent wraps pcs filled by runtime.Callers.
+func NewStackContent(pcs []uintptr) Content {
+	return StackContent{pcs: pcs}
+}
+
+func (f StackContent) Type() Type {
+	if len(f.pcs) == 0 {
+		return TypeArray | TypeNull
+	}
+	return TypeArray | TypeObject
+}
+
+func (f StackContent) Data() any { return f.content().Data() }
+
+func (f StackContent) Raw() []uintptr { return f.pcs }
+
+// Frames symbolizes the stack.
+func (f StackContent) Frames() []runtime.Frame {
+	var result = make([]runtime.Frame, 0, len(f.pcs))
+	if len(f.pcs) == 0 {
+		return result
+	}
+	var frames = runtime.CallersFrames(f.pcs)
+	for {
+		var frame, more = frames.Next()
+		result = append(result, frame)
+		if !more {
+			return result
+		}
+	}
+}
+
+func (f StackContent) AppendJSON(dst []byte) []byte {
+	dst = append(dst, '[')
+	for i, frame := range f.Frames() {
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+		dst = appendFrameJSON(dst, frame)
+	}
+	return append(dst, ']')
+}
+
+func (f StackContent) EncodeJSON(buffer Buffer) error {
+	return errWithoutVal(buffer.Write(f.AppendJSON(nil)))
+}
+
+func (f StackContent) EncodeLogfmt(buffer Buffer) error {
+	var text []byte
+	for i, frame := range f.Frames() {
+		if i > 0 {
+			text = append(text, '\n')
+		}
+		text = appendFrameText(text, frame)
+	}
+	return writeLogfmtValue(buffer, text)
+}
+
+// content converts the stack to builtin contents for encoders which have no
+// case for it.
+func (f StackContent) content() Content {
+	var frames = f.Frames()
+	var list = make([]Content, len(frames))
+	for i := 0; i < len(frames); i++ {
+		list[i] = frameContent(frames[i])
+	}
+	return ArrayContent{arrayRaw: list}
+}
+
+// Stack captures the stack of the calling goroutine, skip 0 starts with the
+// caller of Stack.
+func Stack(key string, skip int) Field {
+	var pcs = make([]uintptr, maxStackDepth)
+	var n = runtime.Callers(skip+2, pcs)
+	return Field{Key: key, Content: NewStackContent(pcs[:n])}
+}
+
+// CallerContent holds a program counter, encoded like a frame of StackContent.
+type CallerContent struct {
+	pc uintptr
+}
+
+// NewCallerContent wraps pc filled by runtime.Callers, zero pc encodes null.
+func NewCallerContent(pc uintptr) Content {
+	return CallerContent{pc: pc}
+}
+
+func (f CallerContent) Type() Type {
+	if f.pc == 0 {
+		return TypeNull
+	}
+	return TypeObject
+}
+
+func (f CallerContent) Data() any { return f.content().Data() }
+
+func (f CallerContent) Raw() uintptr { return f.pc }
+
+// Frame symbolizes the caller.
+func (f CallerContent) Frame() runtime.Frame {
+	if f.pc == 0 {
+		return runtime.Frame{}
+	}
+	var frame, _ = runtime.CallersFrames([]uintptr{f.pc}).Next()
+	return frame
+}
+
+func (f CallerContent) AppendJSON(dst []byte) []byte {
+	if f.pc == 0 {
+		return append(dst, "null"...)
+	}
+	return appendFrameJSON(dst, f.Frame())
+}
+
+func (f CallerContent) EncodeJSON(buffer Buffer) error {
+	return errWithoutVal(buffer.Write(f.AppendJSON(nil)))
+}
+
+func (f CallerContent) EncodeLogfmt(buffer Buffer) error {
+	if f.pc == 0 {
+		return NilContent{}.EncodeLogfmt(buffer)
+	}
+	return writeLogfmtValue(buffer, appendFrameText(nil, f.Frame()))
+}
+
+func (f CallerContent) content() Content {
+	if f.pc == 0 {
+		return NilContent{}
+	}
+	return frameContent(f.Frame())
+}
+
+// Caller captures the calling location, skip 0 is the caller of Caller.
+func Caller(key string, skip int) Field {
+	var pcs [1]uintptr
+	runtime.Callers(skip+2, pcs[:])
+	return Field{Key: key, Content: NewCallerContent(pcs[0])}
+}
+
+func frameContent(frame runtime.Frame) Content {
+	return ObjectContent{fields: Fields{
+		String("func", frame.Function),
+		String("file", frame.File),
+		Int("line", frame.Line),
+	}}
+}
+
+func appendFrameJSON(dst []byte, frame runtime.Frame) []byte {
+	dst = append(dst, `{"func":`...)
+	dst = appendString(dst, frame.Function, false)
+	dst = append(dst, `,"file":`...)
+	dst = appendString(dst, frame.File, false)
+	dst = append(dst, `,"line":`...)
+	dst = strconv.AppendInt(dst, int64(frame.Line), 10)
+	return append(dst, '}')
+}
+
+func appendFrameText(dst []byte, frame runtime.Frame) []byte {
+	dst = append(dst, frame.Function...)
+	dst = append(dst, ' ')
+	dst = append(dst, frame.File...)
+	dst = append(dst, ':')
+	return strconv.AppendInt(dst, int64(frame.Line), 10)
+}
@@ -0,0 +1,89 @@
+package field
+
+import (
+	"bytes"
+	"encoding/json"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+type testFrame struct {
+	Func string `json:"func"`
+	File string `json:"file"`
+	Line int    `json:"line"`
+}
+
+func TestStackField(t *testing.T) {
+	var stack = Stack("stack", 0)
+	var _, _, line, _ = runtime.Caller(0)
+	line--
+	t.Run("json", func(t *testing.T) {
+		var frames []testFrame
+		if err := json.Unmarshal(stack.AppendJSON(nil)[len(`"stack":`):], &frames); err != nil {
+			t.Errorf("cant unmarshal stack: %v", err)
+			return
+		}
+		if len(frames) < 2 || !strings.HasSuffix(frames[0].Func, ".TestStackField") ||
+			!strings.HasSuffix(frames[0].File, "stack_test.go") || frames[0].Line != line {
+			t.Errorf("invalid stack: %+v, expected line: %d", frames, line)
+			return
+		}
+	})
+	t.Run("logfmt", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := (Fields{stack}).EncodeLogfmt(&buf); err != nil {
+			t.Errorf("cant encode stack: %v", err)
+			return
+		}
+		var expected = "stack_test.go:" + strconv.Itoa(line) + `\n`
+		if !strings.HasPrefix(buf.String(), `stack="`) || !strings.Contains(buf.String(), expected) {
+			t.Errorf("invalid logfmt: %s, expected: %s", buf.String(), expected)
+			return
+		}
+	})
+	t.Run("data", func(t *testing.T) {
+		var data, ok = stack.Data().([]any)
+		if !ok || len(data) < 2 {
+			t.Errorf("invalid data: %#v", stack.Data())
+			return
+		}
+		if frame, _ := data[0].(map[string]any); frame["line"] != line {
+			t.Errorf("invalid frame: %#v, expected line: %d", data[0], line)
+			return
+		}
+	})
+	t.Run("empty", func(t *testing.T) {
+		var empty = Stack("stack", 1<<10)
+		if result := string(empty.AppendJSON(nil)); result != `"stack":[]` || empty.Type() != TypeArray|TypeNull {
+			t.Errorf("invalid empty stack: %s", result)
+			return
+		}
+	})
+}
+
+func TestCallerField(t *testing.T) {
+	var caller = Caller("caller", 0)
+	var _, file, line, _ = runtime.Caller(0)
+	line--
+	var expected = `"caller":{"func":"github.com/go-haru/field.TestCallerField","file":"` + file + `","line":` + strconv.Itoa(line) + `}`
+	if result := string(caller.AppendJSON(nil)); result != expected {
+		t.Errorf("invalid caller: %s, expected: %s", result, expected)
+		return
+	}
+	var buf bytes.Buffer
+	if err := (Fields{caller}).EncodeLogfmt(&buf); err != nil {
+		t.Errorf("cant encode caller: %v", err)
+		return
+	}
+	if expected = `caller="github.com/go-haru/field.TestCallerField ` + file + ":" + strconv.Itoa(line) + `"`; buf.String() != expected {
+		t.Errorf("invalid logfmt: %s, expected: %s", buf.String(), expected)
+		return
+	}
+	buf.Reset()
+	if err := (CBOREncoder{}).Encode(&buf, Fields{Caller("caller", 1<<10)}); err != nil || buf.String() != "\xa1\x66caller\xf6" {
+		t.Errorf("invalid cbor: %x, %v", buf.Bytes(), err)
+		return
+	}
+}
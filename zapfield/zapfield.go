@@ -0,0 +1,317 @@
+// Package zapfield converts between field.Field and zapcore.Field, so fields
+// produced by go-haru packages can be logged through zap and the other way.
+package zapfield
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"math"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/go-haru/field"
+)
+
+// ToZap converts f to zapcore.Field with the typed zap constructor matching
+// its content, so no reflection happens on encoding. The redaction policy and
+// the size limits of package field are applied like field.Fields.Snapshot.
+func ToZap(f field.Field) zapcore.Field { return toZap(field.Fields{f}.Snapshot()[0]) }
+
+func toZap(f field.Field) zapcore.Field {
+	switch v := f.Content.(type) {
+	case nil, field.NilContent:
+		return zap.Reflect(f.Key, nil)
+	case field.LazyContent:
+		return toZap(field.Field{Key: f.Key, Content: v.Raw()})
+	case field.BoolContent:
+		return zap.Bool(f.Key, v.Raw())
+	case field.Float32Content:
+		return zap.Float32(f.Key, v.Raw())
+	case field.Float64Content:
+		return zap.Float64(f.Key, v.Raw())
+	case field.Complex64Content:
+		return zap.Complex64(f.Key, v.Raw())
+	case field.Complex128Content:
+		return zap.Complex128(f.Key, v.Raw())
+	case field.StringContent:
+		return zap.String(f.Key, v.Raw())
+	case field.BinaryContent:
+		return zap.Binary(f.Key, v.Raw())
+	case field.TimeContent:
+		return zap.Time(f.Key, v.Raw())
+	case field.UintptrContent:
+		return zap.Uintptr(f.Key, v.Raw())
+	case field.ErrorContent:
+		if v.Raw() == nil {
+			return zap.Reflect(f.Key, nil)
+		}
+		if _, panicked := field.SafeError(v.Raw()); panicked != nil {
+			return toZap(field.Field{Key: f.Key, Content: panicked})
+		}
+		return zap.NamedError(f.Key, v.Raw())
+	case field.StringerContent:
+		switch data := v.Raw().(type) {
+		case nil:
+			return zap.Reflect(f.Key, nil)
+		case time.Duration:
+			return zap.Duration(f.Key, data)
+		default:
+			if text, panicked := field.SafeString(data); panicked != nil {
+				return toZap(field.Field{Key: f.Key, Content: panicked})
+			} else {
+				return zap.String(f.Key, text)
+			}
+		}
+	case field.ArrayContent:
+		return zap.Array(f.Key, arrayMarshaler(v.Raw()))
+	case field.ObjectContent:
+		return zap.Object(f.Key, ObjectMarshaler(v.Raw()))
+	case field.JSONContent:
+		if decoded, err := (field.DecoderConfig{}).DecodeContent(v.Raw()); err == nil {
+			return toZap(field.Field{Key: f.Key, Content: decoded})
+		}
+		return zap.ByteString(f.Key, v.Raw())
+	}
+	switch f.Type() {
+	case field.TypeInt:
+		if i, ok := field.IntData(f.Content); ok {
+			return zap.Int64(f.Key, i)
+		}
+	case field.TypeUint:
+		if u, ok := field.UintData(f.Content); ok {
+			return zap.Uint64(f.Key, u)
+		}
+	}
+	var buf bytes.Buffer
+	if err := f.Content.EncodeJSON(&buf); err != nil {
+		return zap.NamedError(f.Key, err)
+	}
+	return toZap(field.JsonRawMessage(f.Key, buf.Bytes()))
+}
+
+// ToZapFields converts the snapshot of f to []zapcore.Field.
+func ToZapFields(f field.Fields) []zapcore.Field {
+	var snap = f.Snapshot()
+	var result = make([]zapcore.Field, len(snap))
+	for i := 0; i < len(snap); i++ {
+		result[i] = toZap(snap[i])
+	}
+	return result
+}
+
+// ObjectMarshaler implements zapcore.ObjectMarshaler for field.Fields, which
+// cant have the method itself without depending on zap.
+type ObjectMarshaler field.Fields
+
+func (m ObjectMarshaler) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	var snap = field.Fields(m).Snapshot()
+	for i := 0; i < len(snap); i++ {
+		toZap(snap[i]).AddTo(enc)
+	}
+	return nil
+}
+
+type arrayMarshaler []field.Content
+
+func (m arrayMarshaler) MarshalLogArray(enc zapcore.ArrayEncoder) (err error) {
+	for i := 0; i < len(m); i++ {
+		if err = appendContent(enc, m[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func appendContent(enc zapcore.ArrayEncoder, content field.Content) error {
+	switch v := content.(type) {
+	case nil, field.NilContent:
+		return enc.AppendReflected(nil)
+	case field.LazyContent:
+		return appendContent(enc, v.Raw())
+	case field.BoolContent:
+		enc.AppendBool(v.Raw())
+	case field.Float32Content:
+		enc.AppendFloat32(v.Raw())
+	case field.Float64Content:
+		enc.AppendFloat64(v.Raw())
+	case field.Complex64Content:
+		enc.AppendComplex64(v.Raw())
+	case field.Complex128Content:
+		enc.AppendComplex128(v.Raw())
+	case field.StringContent:
+		enc.AppendString(v.Raw())
+	case field.BinaryContent:
+		enc.AppendString(base64.StdEncoding.EncodeToString(v.Raw()))
+	case field.TimeContent:
+		enc.AppendTime(v.Raw())
+	case field.UintptrContent:
+		enc.AppendUintptr(v.Raw())
+	case field.ErrorContent:
+		if v.Raw() == nil {
+			return enc.AppendReflected(nil)
+		}
+		if text, panicked := field.SafeError(v.Raw()); panicked != nil {
+			return appendContent(enc, panicked)
+		} else {
+			enc.AppendString(text)
+		}
+	case field.StringerContent:
+		switch data := v.Raw().(type) {
+		case nil:
+			return enc.AppendReflected(nil)
+		case time.Duration:
+			enc.AppendDuration(data)
+		default:
+			if text, panicked := field.SafeString(data); panicked != nil {
+				return appendContent(enc, panicked)
+			} else {
+				enc.AppendString(text)
+			}
+		}
+	case field.ArrayContent:
+		return enc.AppendArray(arrayMarshaler(v.Raw()))
+	case field.ObjectContent:
+		return enc.AppendObject(ObjectMarshaler(v.Raw()))
+	case field.JSONContent:
+		if decoded, err := (field.DecoderConfig{}).DecodeContent(v.Raw()); err == nil {
+			return appendContent(enc, decoded)
+		}
+		enc.AppendByteString(v.Raw())
+	default:
+		switch content.Type() {
+		case field.TypeInt:
+			if i, ok := field.IntData(content); ok {
+				enc.AppendInt64(i)
+				return nil
+			}
+		case field.TypeUint:
+			if u, ok := field.UintData(content); ok {
+				enc.AppendUint64(u)
+				return nil
+			}
+		}
+		var buf bytes.Buffer
+		if err := content.EncodeJSON(&buf); err != nil {
+			return err
+		}
+		return appendContent(enc, field.NewJSONContent(buf.Bytes()))
+	}
+	return nil
+}
+
+// FromZap converts zf to field.Field. Namespaces and skipped fields are only
+// meaningful in a list, see FromZapFields, they become nil here; so do
+// fields whose Interface is nil or not of the type their Type promises.
+func FromZap(zf zapcore.Field) field.Field {
+	switch zf.Type {
+	case zapcore.BoolType:
+		return field.Bool(zf.Key, zf.Integer == 1)
+	case zapcore.Int64Type:
+		return field.Int64(zf.Key, zf.Integer)
+	case zapcore.Int32Type:
+		return field.Int32(zf.Key, int32(zf.Integer))
+	case zapcore.Int16Type:
+		return field.Int16(zf.Key, int16(zf.Integer))
+	case zapcore.Int8Type:
+		return field.Int8(zf.Key, int8(zf.Integer))
+	case zapcore.Uint64Type:
+		return field.Uint64(zf.Key, uint64(zf.Integer))
+	case zapcore.Uint32Type:
+		return field.Uint32(zf.Key, uint32(zf.Integer))
+	case zapcore.Uint16Type:
+		return field.Uint16(zf.Key, uint16(zf.Integer))
+	case zapcore.Uint8Type:
+		return field.Uint8(zf.Key, uint8(zf.Integer))
+	case zapcore.UintptrType:
+		return field.Uintptr(zf.Key, uintptr(zf.Integer))
+	case zapcore.Float64Type:
+		return field.Float64(zf.Key, math.Float64frombits(uint64(zf.Integer)))
+	case zapcore.Float32Type:
+		return field.Float32(zf.Key, math.Float32frombits(uint32(zf.Integer)))
+	case zapcore.Complex128Type:
+		if c, ok := zf.Interface.(complex128); ok {
+			return field.Complex128(zf.Key, c)
+		}
+	case zapcore.Complex64Type:
+		if c, ok := zf.Interface.(complex64); ok {
+			return field.Complex64(zf.Key, c)
+		}
+	case zapcore.StringType:
+		return field.String(zf.Key, zf.String)
+	case zapcore.BinaryType:
+		if b, ok := zf.Interface.([]byte); ok {
+			return field.Binary(zf.Key, b)
+		}
+	case zapcore.ByteStringType:
+		if b, ok := zf.Interface.([]byte); ok {
+			return field.ByteString(zf.Key, b)
+		}
+	case zapcore.DurationType:
+		return field.Duration(zf.Key, time.Duration(zf.Integer))
+	case zapcore.TimeType:
+		if loc, ok := zf.Interface.(*time.Location); ok {
+			return field.Time(zf.Key, time.Unix(0, zf.Integer).In(loc))
+		}
+		return field.Time(zf.Key, time.Unix(0, zf.Integer))
+	case zapcore.TimeFullType:
+		if t, ok := zf.Interface.(time.Time); ok {
+			return field.Time(zf.Key, t)
+		}
+	case zapcore.ErrorType:
+		if err, ok := zf.Interface.(error); ok {
+			return field.Error(zf.Key, err)
+		}
+	case zapcore.StringerType:
+		if s, ok := zf.Interface.(fmt.Stringer); ok {
+			return field.Stringer(zf.Key, s)
+		}
+	case zapcore.ReflectType:
+		return field.Any(zf.Key, zf.Interface)
+	case zapcore.ObjectMarshalerType:
+		if fields, ok := zf.Interface.(ObjectMarshaler); ok {
+			return field.Object(zf.Key, field.Fields(fields)...)
+		}
+		if _, ok := zf.Interface.(zapcore.ObjectMarshaler); ok {
+			return fromMarshaler(zf)
+		}
+	case zapcore.InlineMarshalerType:
+		if _, ok := zf.Interface.(zapcore.ObjectMarshaler); ok {
+			return fromMarshaler(zf)
+		}
+	case zapcore.ArrayMarshalerType:
+		if _, ok := zf.Interface.(zapcore.ArrayMarshaler); ok {
+			return fromMarshaler(zf)
+		}
+	}
+	return field.Nil(zf.Key)
+}
+
+// fromMarshaler materializes marshalers through a map encoder, an inline
+// marshaler writes its members right into the map.
+func fromMarshaler(zf zapcore.Field) field.Field {
+	var enc = zapcore.NewMapObjectEncoder()
+	zf.AddTo(enc)
+	if zf.Type == zapcore.InlineMarshalerType {
+		return field.Map(zf.Key, enc.Fields)
+	}
+	return field.Any(zf.Key, enc.Fields[zf.Key])
+}
+
+// FromZapFields converts zfs to field.Fields, fields following a namespace are
+// nested in an object of its name.
+func FromZapFields(zfs []zapcore.Field) field.Fields {
+	var result = make(field.Fields, 0, len(zfs))
+	for i := 0; i < len(zfs); i++ {
+		switch zfs[i].Type {
+		case zapcore.SkipType:
+			continue
+		case zapcore.NamespaceType:
+			return append(result, field.Object(zfs[i].Key, FromZapFields(zfs[i+1:])...))
+		}
+		result = append(result, FromZap(zfs[i]))
+	}
+	return result
+}
@@ -0,0 +1,142 @@
+package zapfield
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/go-haru/field"
+)
+
+func encodeZapFields(t *testing.T, fields []zapcore.Field) string {
+	var encoder = zapcore.NewJSONEncoder(zapcore.EncoderConfig{EncodeDuration: zapcore.StringDurationEncoder, EncodeTime: zapcore.RFC3339TimeEncoder})
+	var buf, err = encoder.EncodeEntry(zapcore.Entry{}, fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestToZap(t *testing.T) {
+	var list = field.Fields{
+		field.Nil("nil"),
+		field.Bool("bool", true),
+		field.Int8("int8", -1),
+		field.Uint("uint", uint(2)),
+		field.Float64("float", 1.5),
+		field.String("string", "v"),
+		field.Binary("binary", []byte{1, 2}),
+		field.Time("time", time.Unix(0, 0).UTC()),
+		field.Duration("duration", time.Second),
+		field.Error("error", errors.New("boom")),
+		field.Strings("strings", []string{"a", "b"}),
+		field.Object("object", field.Int("b", 2), field.Durations("a", []time.Duration{time.Minute})),
+		field.JsonRawMessage("json", []byte(`{"a":[1,null]}`)),
+		field.LazyAny("lazy", func() any { return time.Minute }),
+	}
+	var expected = `{"binary":"AQI=","bool":true,"duration":"1s","error":"boom","float":1.5,"int8":-1,"json":{"a":[1,null]},"lazy":"1m0s",` +
+		`"nil":null,"object":{"a":["1m0s"],"b":2},"string":"v","strings":["a","b"],"time":"1970-01-01T00:00:00Z","uint":2}` + "\n"
+	if result := encodeZapFields(t, ToZapFields(list)); result != expected {
+		t.Errorf("invalid zap result: %s, expected: %s", result, expected)
+	}
+	for _, zf := range ToZapFields(list) {
+		if zf.Type == zapcore.ReflectType && zf.Interface != nil {
+			t.Errorf("unexpected reflect field %q", zf.Key)
+		}
+	}
+}
+
+func TestFromZap(t *testing.T) {
+	var testErr = errors.New("boom")
+	var timeVal = time.Unix(100, 0)
+	var tests = []struct {
+		name   string
+		field  zapcore.Field
+		expect field.Field
+	}{
+		{"Bool", zap.Bool("k", true), field.Bool("k", true)},
+		{"Int32", zap.Int32("k", -3), field.Int32("k", -3)},
+		{"Uint16", zap.Uint16("k", 3), field.Uint16("k", 3)},
+		{"Float32", zap.Float32("k", 1.5), field.Float32("k", 1.5)},
+		{"Float64", zap.Float64("k", 1.5), field.Float64("k", 1.5)},
+		{"String", zap.String("k", "v"), field.String("k", "v")},
+		{"Binary", zap.Binary("k", []byte{1}), field.Binary("k", []byte{1})},
+		{"ByteString", zap.ByteString("k", []byte("v")), field.ByteString("k", []byte("v"))},
+		{"Duration", zap.Duration("k", time.Second), field.Duration("k", time.Second)},
+		{"Time", zap.Time("k", timeVal), field.Time("k", timeVal)},
+		{"Error", zap.Error(testErr), field.Error("error", testErr)},
+		{"Reflect", zap.Reflect("k", []string{"a"}), field.Strings("k", []string{"a"})},
+		{"Object", zap.Object("k", ObjectMarshaler{field.Int("a", 1)}), field.Object("k", field.Int("a", 1))},
+		{"Strings", zap.Strings("k", []string{"a"}), field.Any("k", []any{"a"})},
+		{"NilStringer", zap.Stringer("k", nil), field.Nil("k")},
+		{"NilError", zapcore.Field{Key: "k", Type: zapcore.ErrorType}, field.Nil("k")},
+		{"NilObject", zap.Object("k", nil), field.Nil("k")},
+		{"NilArray", zap.Array("k", nil), field.Nil("k")},
+		{"NilBinary", zapcore.Field{Key: "k", Type: zapcore.BinaryType}, field.Nil("k")},
+		{"WrongComplex", zapcore.Field{Key: "k", Type: zapcore.Complex128Type, Interface: "x"}, field.Nil("k")},
+		{"WrongTime", zapcore.Field{Key: "k", Type: zapcore.TimeFullType, Interface: 1}, field.Nil("k")},
+		{"WrongStringer", zapcore.Field{Key: "k", Type: zapcore.StringerType, Interface: 1}, field.Nil("k")},
+		{"WrongBytes", zapcore.Field{Key: "k", Type: zapcore.ByteStringType, Interface: "v"}, field.Nil("k")},
+	}
+	for _, testItem := range tests {
+		t.Run(testItem.name, func(t *testing.T) {
+			var result, _ = field.Fields{FromZap(testItem.field)}.MarshalJSON()
+			var expected, _ = field.Fields{testItem.expect}.MarshalJSON()
+			if string(result) != string(expected) {
+				t.Errorf("invalid field: %s, expected: %s", result, expected)
+			}
+		})
+	}
+	t.Run("namespace", func(t *testing.T) {
+		var fields = FromZapFields([]zapcore.Field{zap.Int("a", 1), zap.Skip(), zap.Namespace("ns"), zap.Int("b", 2)})
+		var expected = field.Fields{field.Int64("a", 1), field.Object("ns", field.Int64("b", 2))}
+		if !reflect.DeepEqual(fields, expected) {
+			t.Errorf("invalid fields: %#v", fields)
+		}
+	})
+}
+
+func TestRedaction(t *testing.T) {
+	field.SetRedactionPolicy(field.RedactionPolicy{{Keys: []string{"password"}}})
+	defer field.SetRedactionPolicy(nil)
+	var list = field.Fields{field.String("password", "hunter2"), field.Object("user", field.String("password", "hunter2"))}
+	var expected = `{"password":"[REDACTED]","user":{"password":"[REDACTED]"}}` + "\n"
+	if result := encodeZapFields(t, ToZapFields(list)); result != expected {
+		t.Errorf("invalid zap result: %s, expected: %s", result, expected)
+	}
+	if result := encodeZapFields(t, []zapcore.Field{zap.Object("ctx", ObjectMarshaler(list))}); result != `{"ctx":`+expected[:len(expected)-1]+"}\n" {
+		t.Errorf("invalid zap object: %s", result)
+	}
+	if result := encodeZapFields(t, []zapcore.Field{ToZap(list[0])}); result != `{"password":"[REDACTED]"}`+"\n" {
+		t.Errorf("invalid zap field: %s", result)
+	}
+}
+
+type testPanicValue struct{ msg string }
+
+func (v *testPanicValue) Error() string { return v.msg }
+
+func (v *testPanicValue) String() string { return v.msg }
+
+func TestPanicRecovery(t *testing.T) {
+	var nilValue *testPanicValue
+	var list = field.Fields{
+		field.Error("error", nilValue),
+		field.Stringer("stringer", nilValue),
+		field.Errors("errors", []error{nilValue}),
+		field.Any("stringers", []fmt.Stringer{nilValue}),
+	}
+	var result = encodeZapFields(t, ToZapFields(list))
+	for _, key := range []string{`"error":{"!PANIC"`, `"stringer":{"!PANIC"`, `"errors":[{"!PANIC"`, `"stringers":[{"!PANIC"`} {
+		if !strings.Contains(result, key) {
+			t.Errorf("invalid zap result: %s, expected: %s", result, key)
+			return
+		}
+	}
+}
@@ -0,0 +1,191 @@
+// Package zerologfield writes field.Fields onto zerolog events and contexts
+// with the typed zerolog methods, so no reflection happens on encoding.
+package zerologfield
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/go-haru/field"
+)
+
+var nullJSON = []byte("null")
+
+// Event appends the snapshot of f to e.
+func Event(e *zerolog.Event, f field.Fields) *zerolog.Event {
+	return e.EmbedObject(ObjectMarshaler(f))
+}
+
+// Context appends the snapshot of f to c, loggers built from it carry them.
+func Context(c zerolog.Context, f field.Fields) zerolog.Context {
+	return c.EmbedObject(ObjectMarshaler(f))
+}
+
+// ObjectMarshaler implements zerolog.LogObjectMarshaler for field.Fields,
+// which cant have the method itself without depending on zerolog.
+type ObjectMarshaler field.Fields
+
+func (m ObjectMarshaler) MarshalZerologObject(e *zerolog.Event) {
+	var snap = field.Fields(m).Snapshot()
+	for i := 0; i < len(snap); i++ {
+		appendField(e, snap[i].Key, snap[i].Content)
+	}
+}
+
+func appendField(e *zerolog.Event, key string, content field.Content) {
+	switch v := content.(type) {
+	case nil, field.NilContent:
+		e.RawJSON(key, nullJSON)
+	case field.LazyContent:
+		appendField(e, key, v.Raw())
+	case field.BoolContent:
+		e.Bool(key, v.Raw())
+	case field.Float32Content:
+		e.Float32(key, v.Raw())
+	case field.Float64Content:
+		e.Float64(key, v.Raw())
+	case field.StringContent:
+		e.Str(key, v.Raw())
+	case field.BinaryContent:
+		e.Bytes(key, v.Raw())
+	case field.TimeContent:
+		e.Time(key, v.Raw())
+	case field.UintptrContent:
+		e.Uint64(key, uint64(v.Raw()))
+	case field.ErrorContent:
+		if v.Raw() == nil {
+			e.RawJSON(key, nullJSON)
+			return
+		}
+		if text, panicked := field.SafeError(v.Raw()); panicked != nil {
+			appendField(e, key, panicked)
+		} else {
+			e.Str(key, text)
+		}
+	case field.StringerContent:
+		switch data := v.Raw().(type) {
+		case nil:
+			e.RawJSON(key, nullJSON)
+		case time.Duration:
+			e.Dur(key, data)
+		default:
+			if text, panicked := field.SafeString(data); panicked != nil {
+				appendField(e, key, panicked)
+			} else {
+				e.Str(key, text)
+			}
+		}
+	case field.ArrayContent:
+		e.Array(key, arrayMarshaler(v.Raw()))
+	case field.ObjectContent:
+		e.Dict(key, zerolog.Dict().EmbedObject(ObjectMarshaler(v.Raw())))
+	case field.JSONContent:
+		e.RawJSON(key, v.Raw())
+	default:
+		switch content.Type() {
+		case field.TypeInt:
+			if i, ok := field.IntData(content); ok {
+				e.Int64(key, i)
+				return
+			}
+		case field.TypeUint:
+			if u, ok := field.UintData(content); ok {
+				e.Uint64(key, u)
+				return
+			}
+		}
+		var buf bytes.Buffer
+		if err := content.EncodeJSON(&buf); err != nil {
+			e.AnErr(key, err)
+			return
+		}
+		e.RawJSON(key, buf.Bytes())
+	}
+}
+
+type arrayMarshaler []field.Content
+
+func (m arrayMarshaler) MarshalZerologArray(a *zerolog.Array) {
+	for i := 0; i < len(m); i++ {
+		appendContent(a, m[i])
+	}
+}
+
+func appendContent(a *zerolog.Array, content field.Content) {
+	switch v := content.(type) {
+	case nil, field.NilContent:
+		a.RawJSON(nullJSON)
+	case field.LazyContent:
+		appendContent(a, v.Raw())
+	case field.BoolContent:
+		a.Bool(v.Raw())
+	case field.Float32Content:
+		a.Float32(v.Raw())
+	case field.Float64Content:
+		a.Float64(v.Raw())
+	case field.StringContent:
+		a.Str(v.Raw())
+	case field.BinaryContent:
+		a.Bytes(v.Raw())
+	case field.TimeContent:
+		a.Time(v.Raw())
+	case field.UintptrContent:
+		a.Uint64(uint64(v.Raw()))
+	case field.ErrorContent:
+		if v.Raw() == nil {
+			a.RawJSON(nullJSON)
+			return
+		}
+		if text, panicked := field.SafeError(v.Raw()); panicked != nil {
+			appendContent(a, panicked)
+		} else {
+			a.Str(text)
+		}
+	case field.StringerContent:
+		switch data := v.Raw().(type) {
+		case nil:
+			a.RawJSON(nullJSON)
+		case time.Duration:
+			a.Dur(data)
+		default:
+			if text, panicked := field.SafeString(data); panicked != nil {
+				appendContent(a, panicked)
+			} else {
+				a.Str(text)
+			}
+		}
+	case field.ArrayContent:
+		// zerolog.Array cant nest arrays, the element is written as JSON text
+		var buf bytes.Buffer
+		if err := v.EncodeJSON(&buf); err != nil {
+			a.Err(err)
+			return
+		}
+		a.RawJSON(buf.Bytes())
+	case field.ObjectContent:
+		a.Object(ObjectMarshaler(v.Raw()))
+	case field.JSONContent:
+		a.RawJSON(v.Raw())
+	default:
+		switch content.Type() {
+		case field.TypeInt:
+			if i, ok := field.IntData(content); ok {
+				a.Int64(i)
+				return
+			}
+		case field.TypeUint:
+			if u, ok := field.UintData(content); ok {
+				a.Uint64(u)
+				return
+			}
+		}
+		var buf bytes.Buffer
+		if err := content.EncodeJSON(&buf); err != nil {
+			a.Err(err)
+			return
+		}
+		a.RawJSON(buf.Bytes())
+	}
+}
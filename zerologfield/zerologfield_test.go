@@ -0,0 +1,86 @@
+package zerologfield
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/go-haru/field"
+)
+
+func TestEvent(t *testing.T) {
+	var list = field.Fields{
+		field.Nil("nil"),
+		field.Bool("bool", true),
+		field.Int8("int8", -1),
+		field.Uint("uint", uint(2)),
+		field.Float64("float", 1.5),
+		field.String("string", "v"),
+		field.Binary("binary", []byte("ab")),
+		field.Time("time", time.Unix(0, 0).UTC()),
+		field.Duration("duration", time.Second),
+		field.Error("error", errors.New("boom")),
+		field.Strings("strings", []string{"a", "b"}),
+		field.Any("nested", [][]int{{1}, {2}}),
+		field.Object("object", field.Int("b", 2), field.Durations("a", []time.Duration{time.Millisecond})),
+		field.JsonRawMessage("json", []byte(`{"a":[1,null]}`)),
+	}
+	var buf bytes.Buffer
+	var logger = zerolog.New(&buf)
+	Event(logger.Log(), list).Send()
+	var expected = `{"binary":"ab","bool":true,"duration":1000,"error":"boom","float":1.5,"int8":-1,"json":{"a":[1,null]},` +
+		`"nested":[[1],[2]],"nil":null,"object":{"a":[1],"b":2},"string":"v","strings":["a","b"],"time":"1970-01-01T00:00:00Z","uint":2}` + "\n"
+	if result := buf.String(); result != expected {
+		t.Errorf("invalid zerolog result: %s, expected: %s", result, expected)
+	}
+}
+
+func TestContext(t *testing.T) {
+	var buf bytes.Buffer
+	var logger = Context(zerolog.New(&buf).With(), field.Fields{field.String("a", "1"), field.String("a", "2")}).Logger()
+	logger.Log().Int("b", 3).Send()
+	if result, expected := buf.String(), `{"a":"1","b":3}`+"\n"; result != expected {
+		t.Errorf("invalid zerolog result: %s, expected: %s", result, expected)
+	}
+}
+
+func TestRedaction(t *testing.T) {
+	field.SetRedactionPolicy(field.RedactionPolicy{{Keys: []string{"password"}}})
+	defer field.SetRedactionPolicy(nil)
+	var buf bytes.Buffer
+	var logger = zerolog.New(&buf)
+	Event(logger.Log(), field.Fields{field.String("password", "hunter2"), field.Object("user", field.String("password", "hunter2"))}).Send()
+	if result, expected := buf.String(), `{"password":"[REDACTED]","user":{"password":"[REDACTED]"}}`+"\n"; result != expected {
+		t.Errorf("invalid zerolog result: %s, expected: %s", result, expected)
+	}
+}
+
+type testPanicValue struct{ msg string }
+
+func (v *testPanicValue) Error() string { return v.msg }
+
+func (v *testPanicValue) String() string { return v.msg }
+
+func TestPanicRecovery(t *testing.T) {
+	var nilValue *testPanicValue
+	var list = field.Fields{
+		field.Error("error", nilValue),
+		field.Stringer("stringer", nilValue),
+		field.Errors("errors", []error{nilValue}),
+		field.Any("stringers", []fmt.Stringer{nilValue}),
+	}
+	var buf bytes.Buffer
+	var logger = zerolog.New(&buf)
+	Event(logger.Log(), list).Send()
+	for _, key := range []string{`"error":{"!PANIC"`, `"stringer":{"!PANIC"`, `"errors":[{"!PANIC"`, `"stringers":[{"!PANIC"`} {
+		if !strings.Contains(buf.String(), key) {
+			t.Errorf("invalid zerolog result: %s, expected: %s", buf.String(), key)
+			return
+		}
+	}
+}